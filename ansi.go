@@ -0,0 +1,237 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Attr holds the colors of a character cell, using the ANSI color
+// numbers 0-7. Bold selects the bright foreground.
+type Attr struct {
+	Fg    int
+	Bg    int
+	Bold  bool
+	Blink bool
+}
+
+// defaultAttr is what ESC[0m resets to: white on black.
+var defaultAttr = Attr{Fg: 7, Bg: 0}
+
+// cellSink receives the characters decoded by ansiState, one cell at a time.
+type cellSink interface {
+	putCell(x, y int, r rune, a Attr)
+	clear()
+}
+
+// ansiState interprets a stream of text and ANSI escape sequences the way a
+// terminal would, tracking the cursor and current attributes. Coordinates
+// are 1-based. Nothing scrolls: text past the last row is dropped.
+type ansiState struct {
+	X, Y  int
+	W, H  int
+	Attr  Attr
+	saveX int
+	saveY int
+	seq   []byte // escape sequence being collected
+	inEsc bool
+}
+
+func newAnsiState(w, h int) ansiState {
+	return ansiState{X: 1, Y: 1, W: w, H: h, Attr: defaultAttr, saveX: 1, saveY: 1}
+}
+
+// MoveTo places the cursor, clamping to the screen.
+func (st *ansiState) MoveTo(x, y int) {
+	st.X = clamp(x, 1, st.W)
+	st.Y = clamp(y, 1, st.H)
+}
+
+// feed interprets s, sending every printed character to sink.
+func (st *ansiState) feed(s string, sink cellSink) {
+	for _, r := range s {
+		if st.inEsc {
+			st.escape(r, sink)
+			continue
+		}
+
+		switch {
+		case r == 0x1b:
+			st.inEsc = true
+			st.seq = st.seq[:0]
+		case r == '\r':
+			st.X = 1
+		case r == '\n':
+			st.X = 1
+			st.Y++
+		case r == '\b':
+			if st.X > 1 {
+				st.X--
+			}
+		case r == '\t':
+			st.X = (st.X-1)/8*8 + 9
+		case r < 0x20 || r == 0x7f:
+			// Other control characters (BEL, SUB...) print nothing
+		default:
+			if st.X > st.W {
+				st.X = 1
+				st.Y++
+			}
+			if st.Y <= st.H {
+				sink.putCell(st.X, st.Y, r, st.Attr)
+			}
+			st.X++
+		}
+	}
+}
+
+// escape collects one more rune of an escape sequence and runs it once
+// the final byte arrives.
+func (st *ansiState) escape(r rune, sink cellSink) {
+	if len(st.seq) == 0 && r != '[' {
+		// Not a CSI sequence (ESC D and friends); drop it
+		st.inEsc = false
+		return
+	}
+	if r < 0x40 || r > 0x7e || len(st.seq) == 0 {
+		st.seq = append(st.seq, byte(r))
+		return
+	}
+	st.inEsc = false
+
+	params := string(st.seq[1:])
+	if strings.HasPrefix(params, "?") {
+		return // private modes (cursor visibility etc.) don't change cells
+	}
+	p := parseParams(params)
+	arg := func(i, def int) int {
+		if i < len(p) && p[i] > 0 {
+			return p[i]
+		}
+		return def
+	}
+
+	switch r {
+	case 'm':
+		st.sgr(p)
+	case 'H', 'f':
+		st.MoveTo(arg(1, 1), arg(0, 1))
+	case 'A':
+		st.MoveTo(st.X, st.Y-arg(0, 1))
+	case 'B':
+		st.MoveTo(st.X, st.Y+arg(0, 1))
+	case 'C':
+		st.MoveTo(st.X+arg(0, 1), st.Y)
+	case 'D':
+		st.MoveTo(st.X-arg(0, 1), st.Y)
+	case 'E':
+		st.MoveTo(1, st.Y+arg(0, 1))
+	case 'F':
+		st.MoveTo(1, st.Y-arg(0, 1))
+	case 'G':
+		st.MoveTo(arg(0, 1), st.Y)
+	case 'd':
+		st.MoveTo(st.X, arg(0, 1))
+	case 's':
+		st.saveX, st.saveY = st.X, st.Y
+	case 'u':
+		st.MoveTo(st.saveX, st.saveY)
+	case 'J':
+		switch arg(0, 0) {
+		case 2:
+			sink.clear()
+			st.X, st.Y = 1, 1
+		case 0:
+			st.erase(st.X, st.Y, st.W, st.H, sink)
+		case 1:
+			st.erase(1, 1, st.X, st.Y, sink)
+		}
+	case 'K':
+		switch arg(0, 0) {
+		case 0:
+			st.erase(st.X, st.Y, st.W, st.Y, sink)
+		case 1:
+			st.erase(1, st.Y, st.X, st.Y, sink)
+		case 2:
+			st.erase(1, st.Y, st.W, st.Y, sink)
+		}
+	}
+}
+
+// erase blanks the cells from (x1,y1) to (x2,y2) in reading order.
+func (st *ansiState) erase(x1, y1, x2, y2 int, sink cellSink) {
+	for y := y1; y <= y2 && y <= st.H; y++ {
+		from, to := 1, st.W
+		if y == y1 {
+			from = x1
+		}
+		if y == y2 {
+			to = x2
+		}
+		for x := from; x <= to; x++ {
+			sink.putCell(x, y, ' ', st.Attr)
+		}
+	}
+}
+
+// sgr applies a Select Graphic Rendition parameter list.
+func (st *ansiState) sgr(p []int) {
+	if len(p) == 0 {
+		p = []int{0}
+	}
+	for _, n := range p {
+		switch {
+		case n == 0:
+			st.Attr = defaultAttr
+		case n == 1:
+			st.Attr.Bold = true
+		case n == 5:
+			st.Attr.Blink = true
+		case n == 22:
+			st.Attr.Bold = false
+		case n == 25:
+			st.Attr.Blink = false
+		case n >= 30 && n <= 37:
+			st.Attr.Fg = n - 30
+		case n == 39:
+			st.Attr.Fg = defaultAttr.Fg
+		case n >= 40 && n <= 47:
+			st.Attr.Bg = n - 40
+		case n == 49:
+			st.Attr.Bg = defaultAttr.Bg
+		}
+	}
+}
+
+// sgrString renders an Attr as a full SGR sequence.
+func sgrString(a Attr) string {
+	s := Esc + "0"
+	if a.Bold {
+		s += ";1"
+	}
+	if a.Blink {
+		s += ";5"
+	}
+	return s + ";" + strconv.Itoa(30+a.Fg) + ";" + strconv.Itoa(40+a.Bg) + "m"
+}
+
+func parseParams(s string) []int {
+	if s == "" {
+		return nil
+	}
+	fields := strings.Split(s, ";")
+	p := make([]int, len(fields))
+	for i, f := range fields {
+		p[i], _ = strconv.Atoi(f)
+	}
+	return p
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
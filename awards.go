@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"strings"
 )
 
@@ -12,6 +11,8 @@ type Award struct {
 	AwardConditions []string // conditions to earn the award
 	RunDownClock    bool     // award is earned by letting the clock run down
 	OnMainMenu      bool     // award is earned from the main menu
+	Speed           bool     // award is for winning the race; not given to pasted runs
+	Difficulty      string   // award can only be earned on this difficulty, if set
 	Required        []string // required awards to earn this one
 	Optional        []string // optional awards to earn this one
 }
@@ -24,6 +25,7 @@ var awards = []Award{
 		AwardConditions: []string{"pull door", "remove pants", "sit toilet", "shit"},
 		RunDownClock:    false,
 		OnMainMenu:      false,
+		Speed:           true,
 		Required:        nil,
 		Optional:        nil,
 	},
@@ -34,6 +36,7 @@ var awards = []Award{
 		AwardConditions: []string{"remove pants", "shit"},
 		RunDownClock:    false,
 		OnMainMenu:      false,
+		Speed:           true,
 		Required:        nil,
 		Optional:        nil,
 	},
@@ -116,11 +119,33 @@ var awards = []Award{
 		OnMainMenu:      false,
 		Required:        []string{"1", "2", "3", "4", "5", "6", "7", "8", "9"},
 	},
+	{
+		ID:              "11",
+		Name:            "Dairy Queen",
+		Description:     "Your one fart bought just enough time. Lactose be damned.",
+		AwardConditions: []string{"fart lightly", "remove pants", "shit"},
+		RunDownClock:    false,
+		OnMainMenu:      false,
+		Difficulty:      "Lactose Intolerant",
+		Required:        nil,
+		Optional:        nil,
+	},
+	{
+		ID:              "12",
+		Name:            "Ring of Fire",
+		Description:     "You farted your way through the chili and still made it. Godspeed.",
+		AwardConditions: []string{"fart lightly", "pull door", "remove pants", "shit"},
+		RunDownClock:    false,
+		OnMainMenu:      false,
+		Difficulty:      "Chili Night",
+		Required:        nil,
+		Optional:        nil,
+	},
 
 	// Define more awards as needed
 }
 
-func (g *Game) checkAndGrantAwards(inputChan chan byte) {
+func (g *Game) checkAndGrantAwards(inputChan chan Key) {
 	// Check if the user is in the main menu state
 	isMainMenu := g.GameState.AppState == stateMainMenu
 
@@ -130,9 +155,29 @@ func (g *Game) checkAndGrantAwards(inputChan chan byte) {
 		// Add more mappings as needed
 	}
 
-	// Iterate over awards
+	// Awards for the level being played come first, so the everyday ones
+	// that ask for less can't get in ahead of them
+	ordered := make([]Award, 0, len(awards))
+	for _, award := range awards {
+		if award.Difficulty != "" {
+			ordered = append(ordered, award)
+		}
+	}
 	for _, award := range awards {
+		if award.Difficulty == "" {
+			ordered = append(ordered, award)
+		}
+	}
+
+	// Iterate over awards
+	for _, award := range ordered {
 		// Check if the user has not already earned the award and is eligible to earn it based on MainMenu field
+		if award.Speed && g.GameState.Pasted {
+			continue
+		}
+		if award.Difficulty != "" && award.Difficulty != g.difficulty().Name {
+			continue
+		}
 		if !g.User.Awards[award.ID] && ((award.OnMainMenu && isMainMenu) || (!award.OnMainMenu && !isMainMenu)) {
 			// Initialize a flag to track if all conditions are met
 			allConditionsMet := true
@@ -189,7 +234,7 @@ func (g *Game) checkAndGrantAwards(inputChan chan byte) {
 
 				// Display the award message
 				ClearScreen()
-				fmt.Printf("Congratulations! You've earned the %s award!\n", award.Name)
+				Printf("Congratulations! You've earned the %s award!\n", award.Name)
 
 				// Pause for a keypress
 				g.readSingleKeyPress(inputChan, stateAwards)
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Bulletin size: 80 columns, as tall as the tables need
+const (
+	bulletinW    = 80
+	bulletinH    = 60
+	bulletinLogo = 16 // rows of the main menu art kept as the header
+)
+
+// runBulletin is the `dsyp bulletin` command: it writes the hall of fame
+// as an ANSI bulletin, and a plain text copy beside it, for the BBS to show
+// at logon. Sysops run it from a nightly event.
+func runBulletin(args []string) error {
+	flags := flag.NewFlagSet("bulletin", flag.ExitOnError)
+	outPtr := flags.String("o", "scores.ans", "ANSI file to write; plain text goes in the same name ending .asc")
+	dataPtr := flags.String("data", dataDir, "directory for files shared by all nodes, like the hall of fame")
+	flags.Parse(args)
+	dataDir = *dataPtr
+
+	runs, err := loadRuns()
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// Draw it the way the door would, on a screen nobody is watching
+	term = newANSIRenderer(os.Stdout, true, bulletinW, bulletinH)
+	screen = NewScreen(term)
+	g := &Game{User: User{W: bulletinW, H: bulletinH}}
+	g.drawBulletin(runs)
+
+	ans := *outPtr
+	if err := os.WriteFile(ans, []byte(screenANSI(screen)), 0644); err != nil {
+		return err
+	}
+	asc := strings.TrimSuffix(ans, ".ans") + ".asc"
+	return os.WriteFile(asc, []byte(screenText(screen)), 0644)
+}
+
+// drawBulletin puts the door's logo over the hall of fame and the roster of
+// Shit Kings.
+func (g *Game) drawBulletin(runs []Run) {
+	ClearScreen()
+	if art, err := ReadAnsiFile(ArtFileDir + "main.ans"); err == nil {
+		PrintAnsi(art, 0)
+	}
+	screen.Fill(1, bulletinLogo+1, bulletinW, bulletinH-bulletinLogo, defaultAttr)
+	screen.MoveTo(1, bulletinLogo+1)
+
+	g.showScores()
+	Println("")
+	g.printScoreTable("Shit Kings", "Crowned", "Awards", shitKings(runs), true,
+		func(r Run) string { return r.When.Format("2006-01-02") },
+		func(r Run) string { return strconv.Itoa(len(r.Awards)) })
+}
+
+// shitKings are the players who have earned the Final Award, in the order
+// they were crowned.
+func shitKings(runs []Run) []Run {
+	crowned := map[string]bool{}
+	var kings []Run
+	for _, run := range runs {
+		if crowned[run.Alias] || !hasAward(run, "10") {
+			continue
+		}
+		crowned[run.Alias] = true
+		kings = append(kings, run)
+	}
+	sort.SliceStable(kings, func(i, j int) bool { return kings[i].When.Before(kings[j].When) })
+	return kings
+}
+
+func hasAward(run Run, id string) bool {
+	for _, award := range run.Awards {
+		if award == id {
+			return true
+		}
+	}
+	return false
+}
+
+// screenRows is how many rows of s have anything on them.
+func screenRows(s *Screen) int {
+	for y := s.H; y > 0; y-- {
+		for x := 1; x <= s.W; x++ {
+			if !s.cells[(y-1)*s.W+x-1].same(blankCell) {
+				return y
+			}
+		}
+	}
+	return 0
+}
+
+// screenANSI writes out s line by line as CP437 ANSI, for a bulletin that
+// scrolls instead of jumping the cursor about.
+func screenANSI(s *Screen) string {
+	var b strings.Builder
+	r := newANSIRenderer(&b, true, s.W, s.H)
+	r.Write(Reset + EraseScreen)
+	for y := 1; y <= screenRows(s); y++ {
+		row := s.cells[(y-1)*s.W : y*s.W]
+		end := len(row)
+		for end > 0 && row[end-1].same(blankCell) {
+			end--
+		}
+		var last Attr
+		for x, c := range row[:end] {
+			if x == 0 || c.Attr != last {
+				r.SetAttr(c.Attr)
+				last = c.Attr
+			}
+			r.Write(string(c.Ch))
+		}
+		r.Write(Reset + "\r\n")
+	}
+	r.Flush()
+	return b.String()
+}
+
+// screenText is s as CP437 text without colors.
+func screenText(s *Screen) string {
+	var b strings.Builder
+	r := newANSIRenderer(&b, true, s.W, s.H)
+	for y := 1; y <= screenRows(s); y++ {
+		var line []rune
+		for _, c := range s.cells[(y-1)*s.W : y*s.W] {
+			line = append(line, c.Ch)
+		}
+		r.Write(strings.TrimRight(string(line), " ") + "\r\n")
+	}
+	r.Flush()
+	return b.String()
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+// Challenge is the day's daily challenge. Everything about it comes from
+// the date, so every caller gets the same one.
+type Challenge struct {
+	Date       string
+	Difficulty Difficulty
+	DoorVerb   string // the only verb that gets the door open today
+	Events     []ChallengeEvent
+}
+
+// ChallengeEvent is something that happens when the countdown reaches Left.
+type ChallengeEvent struct {
+	Left  time.Duration
+	Event int // index into challengeEvents
+}
+
+// challengeEvents are the surprises a daily challenge can spring.
+var challengeEvents = []struct {
+	Message string
+	Effect  func(g *Game)
+}{
+	{"Your stomach gurgles ominously.", func(g *Game) { g.loseTime(3 * time.Second) }},
+	{"A cramp doubles you over!", func(g *Game) { g.loseTime(5 * time.Second) }},
+	{"A draft slams the door shut!", func(g *Game) {
+		if g.GameState.Standing {
+			g.GameState.Door = false
+		}
+	}},
+	{"You feel a moment of calm.", func(g *Game) { g.GameState.RemainingTime += 5 * time.Second }},
+}
+
+// doorVerbs are the verbs the daily challenge picks the door's from.
+var doorVerbs = []string{"pull", "yank", "push", "open"}
+
+// challengeEventCount is how many surprises a daily challenge has.
+const challengeEventCount = 2
+
+// dailyChallenge makes the challenge for the day of t.
+func dailyChallenge(t time.Time) *Challenge {
+	date := t.Format("2006-01-02")
+	h := fnv.New64a()
+	h.Write([]byte(date))
+	rng := rand.New(rand.NewSource(int64(h.Sum64())))
+
+	d := Difficulty{
+		Name:         "Daily Challenge",
+		StartTime:    time.Duration(25+rng.Intn(21)) * time.Second,
+		FartBonus:    time.Duration(15+5*rng.Intn(7)) * time.Second,
+		AllowedFarts: 1 + rng.Intn(3),
+		PillDelay:    time.Duration(30+rng.Intn(31)) * time.Second,
+	}
+	c := &Challenge{Date: date, Difficulty: d, DoorVerb: doorVerbs[rng.Intn(len(doorVerbs))]}
+	for i := 0; i < challengeEventCount; i++ {
+		left := time.Duration(3+rng.Intn(int(d.StartTime/time.Second)-8)) * time.Second
+		c.Events = append(c.Events, ChallengeEvent{Left: left, Event: rng.Intn(len(challengeEvents))})
+	}
+	return c
+}
+
+// scheduleChallenge sets up the day's surprises for a new round.
+func (g *Game) scheduleChallenge() {
+	if g.challenge == nil {
+		return
+	}
+	for _, e := range g.challenge.Events {
+		event := challengeEvents[e.Event]
+		g.at(e.Left, func() {
+			event.Effect(g)
+			g.message(BgBlue + MagentaHi + event.Message + Reset)
+		})
+	}
+}
+
+// loseTime takes time off the clock, leaving at least a second.
+func (g *Game) loseTime(d time.Duration) {
+	g.GameState.RemainingTime -= d
+	if g.GameState.RemainingTime < time.Second {
+		g.GameState.RemainingTime = time.Second
+	}
+}
+
+// doorOpens reports whether verb gets the door open. Normally only pulling
+// does; the daily challenge picks its own.
+func (g *Game) doorOpens(verb string) bool {
+	if g.challenge != nil {
+		return verb == g.challenge.DoorVerb
+	}
+	return containsWordFromList(verb, pullVerbs)
+}
+
+// startChallenge counts today's attempt against the player and keeps
+// their streak of days played.
+func (g *Game) startChallenge(c *Challenge, now time.Time) {
+	p := &g.profile
+	if p.LastDaily == now.AddDate(0, 0, -1).Format("2006-01-02") {
+		p.DailyStreak++
+	} else {
+		p.DailyStreak = 1
+	}
+	if p.DailyStreak > p.BestStreak {
+		p.BestStreak = p.DailyStreak
+	}
+	p.LastDaily = c.Date
+	g.saveProfile()
+	g.challenge = c
+}
+
+// playDaily shows the day's challenge and its leaderboard, and lets the
+// player have their one go at it.
+func (g *Game) playDaily(inputChan chan Key, errorChan chan error, doneChan chan bool) {
+	now := clock.Now()
+	c := dailyChallenge(now)
+	g.GameState.AppState = stateDaily
+	g.updateGameEnvironment(inputChan)
+	CursorHide()
+
+	// A streak that missed a day is over, even if it hasn't been reset yet
+	streak := g.profile.DailyStreak
+	if g.profile.LastDaily != c.Date && g.profile.LastDaily != now.AddDate(0, 0, -1).Format("2006-01-02") {
+		streak = 0
+	}
+
+	d := c.Difficulty
+	CenterText("DAILY CHALLENGE "+c.Date, g.User.W)
+	Println("")
+	Println(Cyan + "Clock:  " + WhiteHi + formatTimer(d.StartTime) + Reset)
+	Println(Cyan + "Farts:  " + WhiteHi + strconv.Itoa(d.AllowedFarts) + " allowed, +" + formatTimer(d.FartBonus) + " each" + Reset)
+	Println(Cyan + "Pills:  " + WhiteHi + formatTimer(d.PillDelay) + " to work" + Reset)
+	Println(Cyan + "Door:   " + WhiteHi + "not its usual self" + Reset)
+	Println(Cyan + "Streak: " + WhiteHi + strconv.Itoa(streak) + " days (best " + strconv.Itoa(g.profile.BestStreak) + ")" + Reset)
+	Println("")
+
+	runs, err := loadRuns()
+	if err != nil {
+		inputLog(LogLevelError, "SysOp", "Failed to read the leaderboard: "+err.Error())
+	}
+	var today []Run
+	for _, run := range runs {
+		if run.Daily == c.Date {
+			today = append(today, run)
+		}
+	}
+	g.printScoreTable("Today's Best", "Ending", "Score", topRuns(today, leaderboardRows), g.User.W >= 80,
+		func(r Run) string { return string(r.Ending) },
+		func(r Run) string { return strconv.Itoa(r.Score) })
+	Println("")
+
+	if g.profile.LastDaily == c.Date {
+		Println(YellowHi + "You've had your go today. Come back tomorrow!" + Reset)
+		g.readSingleKeyPress(inputChan, stateMainMenu)
+		CursorShow()
+		return
+	}
+
+	Println(YellowHi + "One go a day. Press P to play, any other key to go back." + Reset)
+	Flush()
+	key := <-inputChan
+	CursorShow()
+	if key.Code != KeyRune || (key.Rune != 'p' && key.Rune != 'P') {
+		g.GameState.AppState = stateMainMenu
+		g.updateGameEnvironment(inputChan)
+		return
+	}
+
+	g.startChallenge(c, now)
+	g.GameState.AppState = statePlaying
+	g.startGame(inputChan, errorChan, doneChan)
+	g.challenge = nil
+}
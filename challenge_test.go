@@ -0,0 +1,77 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDailyChallengeSeed(t *testing.T) {
+	morning := time.Date(2026, 3, 14, 0, 0, 1, 0, time.UTC)
+	night := time.Date(2026, 3, 14, 23, 59, 59, 0, time.UTC)
+	if a, b := dailyChallenge(morning), dailyChallenge(night); !reflect.DeepEqual(a, b) {
+		t.Errorf("same day, different challenges:\n%+v\n%+v", a, b)
+	}
+
+	differ := false
+	for day := 0; day < 30; day++ {
+		c := dailyChallenge(morning.AddDate(0, 0, day))
+		d := c.Difficulty
+		if d.StartTime < 25*time.Second || d.StartTime > 45*time.Second ||
+			d.FartBonus < 15*time.Second || d.FartBonus > 45*time.Second ||
+			d.AllowedFarts < 1 || d.AllowedFarts > 3 ||
+			d.PillDelay < 30*time.Second || d.PillDelay > 60*time.Second {
+			t.Errorf("%s: rules out of range: %+v", c.Date, d)
+		}
+		if len(c.Events) != challengeEventCount {
+			t.Errorf("%s: %d events, want %d", c.Date, len(c.Events), challengeEventCount)
+		}
+		for _, e := range c.Events {
+			if e.Left < 3*time.Second || e.Left >= d.StartTime-5*time.Second {
+				t.Errorf("%s: event at %v on a %v clock", c.Date, e.Left, d.StartTime)
+			}
+		}
+		if !reflect.DeepEqual(c.Difficulty, dailyChallenge(morning).Difficulty) {
+			differ = true
+		}
+	}
+	if !differ {
+		t.Errorf("a month of challenges all had the same rules")
+	}
+}
+
+func TestDailyStreak(t *testing.T) {
+	today := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		last       string
+		streak     int
+		best       int
+		wantStreak int
+		wantBest   int
+	}{
+		{"first ever", "", 0, 0, 1, 1},
+		{"played yesterday", "2026-03-13", 4, 4, 5, 5},
+		{"below the best", "2026-03-13", 2, 9, 3, 9},
+		{"missed a day", "2026-03-12", 4, 4, 1, 4},
+		{"weeks ago", "2026-02-28", 6, 6, 1, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGame(t)
+			g.profile = Profile{Alias: "Tester", LastDaily: tt.last, DailyStreak: tt.streak, BestStreak: tt.best}
+			g.startChallenge(dailyChallenge(today), today)
+
+			saved := loadProfile("Tester")
+			if saved.DailyStreak != tt.wantStreak || saved.BestStreak != tt.wantBest {
+				t.Errorf("streak %d (best %d), want %d (best %d)", saved.DailyStreak, saved.BestStreak, tt.wantStreak, tt.wantBest)
+			}
+			if saved.LastDaily != "2026-03-14" {
+				t.Errorf("last daily %q, want 2026-03-14", saved.LastDaily)
+			}
+			if g.challenge == nil {
+				t.Errorf("challenge not started")
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Difficulty sets the timing rules of a round.
+type Difficulty struct {
+	Name         string
+	StartTime    time.Duration // on the clock when the round starts
+	FartBonus    time.Duration // added by each light fart
+	AllowedFarts int           // light farts before the next one stains your pants
+	PillDelay    time.Duration // how long the stomach pills take to work
+}
+
+// difficulties are the levels a player can pick, easiest first.
+var difficulties = []Difficulty{
+	{
+		Name:         "Regular",
+		StartTime:    40 * time.Second,
+		FartBonus:    60 * time.Second,
+		AllowedFarts: 2,
+		PillDelay:    30 * time.Second, // pills taken early can beat the clock unaided
+	},
+	{
+		Name:         "Lactose Intolerant",
+		StartTime:    30 * time.Second,
+		FartBonus:    30 * time.Second,
+		AllowedFarts: 1,
+		PillDelay:    50 * time.Second,
+	},
+	{
+		Name:         "Chili Night",
+		StartTime:    25 * time.Second,
+		FartBonus:    15 * time.Second,
+		AllowedFarts: 3,
+		PillDelay:    60 * time.Second,
+	},
+}
+
+// difficulty returns the level the player has picked, or the daily
+// challenge's rules while they play it.
+func (g *Game) difficulty() Difficulty {
+	if g.challenge != nil {
+		return g.challenge.Difficulty
+	}
+	return difficulties[g.level]
+}
+
+// nextDifficulty moves to the next level, wrapping round to the first, and
+// says what the rules are.
+func (g *Game) nextDifficulty() {
+	g.level = (g.level + 1) % len(difficulties)
+	d := g.difficulty()
+	g.message(fmt.Sprintf(BgBlue+YellowHi+"Difficulty: %s"+CyanHi+" (%s on the clock, +%s a fart, %d allowed)"+Reset,
+		d.Name, formatTimer(d.StartTime), formatTimer(d.FartBonus), d.AllowedFarts))
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strconv"
+	"time"
+)
+
+// Step is something the player got done during a round, and when.
+type Step struct {
+	At  time.Duration // since play began
+	Did string        // in the past tense, as the ghost tells it
+}
+
+// step notes on the round's timeline that the player did something.
+func (g *Game) step(did string) {
+	g.GameState.Timeline = append(g.GameState.Timeline, Step{At: clock.Now().Sub(g.GameState.Started), Did: did})
+}
+
+// personalBest finds alias's fastest win on a level, out of the runs that
+// kept a timeline.
+func personalBest(alias, difficulty string) *Run {
+	runs, err := loadRuns()
+	if err != nil {
+		inputLog(LogLevelError, "SysOp", "Failed to read the leaderboard: "+err.Error())
+		return nil
+	}
+	var best *Run
+	for i, run := range runs {
+		if run.Alias != alias || run.Difficulty != difficulty || run.Daily != "" ||
+			!run.Ending.Won() || len(run.Timeline) == 0 {
+			continue
+		}
+		if best == nil || run.Elapsed < best.Elapsed {
+			best = &runs[i]
+		}
+	}
+	return best
+}
+
+// findGhost sets up the player's personal best to race against. Races and
+// daily challenges have nobody's ghost.
+func (g *Game) findGhost() {
+	g.ghost = nil
+	if g.race == nil && g.challenge == nil {
+		g.ghost = personalBest(g.User.Alias, g.GameState.Difficulty)
+	}
+}
+
+// drawGhost shows on the ticker line what the personal best had done by
+// now, and whether the player is ahead of it. Notices from other nodes
+// get the line while they're up.
+func (g *Game) drawGhost() {
+	if g.ghost == nil || !g.noticeUntil.IsZero() || g.GameState.AppState != statePlaying {
+		return
+	}
+	g.layout.Ticker.Print(BgBlack + CyanHi + "PB: " + WhiteHi + describeGhost(g.ghost, g.GameState.Timeline, clock.Now().Sub(g.GameState.Started)) + Reset)
+}
+
+// describeGhost says where the best run had got to at elapsed, and how
+// the player's steps so far compare.
+func describeGhost(best *Run, mine []Step, elapsed time.Duration) string {
+	if elapsed >= best.Elapsed {
+		return "finished, " + string(best.Ending) + " at " + formatTimer(best.Elapsed)
+	}
+	done := 0
+	for done < len(best.Timeline) && best.Timeline[done].At <= elapsed {
+		done++
+	}
+	text := "nothing yet"
+	if done > 0 {
+		last := best.Timeline[done-1]
+		text = last.Did + " at " + formatTimer(last.At)
+	}
+
+	// Whoever took the player's latest step first is ahead
+	n := len(mine)
+	var lead time.Duration
+	switch {
+	case done > n:
+		// The best run took its next step and the player hasn't yet
+		lead = best.Timeline[n].At - elapsed
+	case n > len(best.Timeline):
+		return text + ", you're ahead"
+	case n > 0:
+		lead = best.Timeline[n-1].At - mine[n-1].At
+	}
+	switch lead = lead.Round(time.Second); {
+	case lead > 0:
+		return text + ", you're " + seconds(lead) + " ahead"
+	case lead < 0:
+		return text + ", you're " + seconds(-lead) + " behind"
+	}
+	return text + ", you're level"
+}
+
+// seconds gives a gap in whole seconds, as "3s".
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(d/time.Second)) + "s"
+}
@@ -126,11 +126,30 @@ const (
 
 var Idle int
 
-// Get info from the Drop File, h, w
-func Initialize(path string) User {
+// Get info from the Drop File, h, w. PETSCII callers can't answer the
+// cursor position query, so they get a fixed 40x25; telnet callers report
+// their size through NAWS.
+func Initialize(path string, petscii bool) User {
 
 	alias, timeLeft, emulation, nodeNum := DropFileData(path)
-	h, w := GetTermSize()
+	if petscii {
+		emulation = EmulationPETSCII
+	}
+
+	var h, w int
+	switch {
+	case emulation == EmulationPETSCII:
+		h, w = 25, 40
+	case telnet.Active():
+		// The cursor query would be answered through the telnet stream;
+		// use the window size the caller negotiated instead.
+		w, h = telnet.Size()
+		if w == 0 {
+			h, w = 25, 80
+		}
+	default:
+		h, w = GetTermSize()
+	}
 
 	if h%2 == 0 {
 		modalH = h
@@ -212,68 +231,70 @@ func Pause(h int, w int) {
 
 // Move cursor to X, Y location
 func MoveCursor(x int, y int) {
-	fmt.Printf(Esc+"%d;%df", y, x)
+	screen.MoveTo(x, y)
 }
 
 // Erase the screen
 func ClearScreen() {
-	fmt.Println(EraseScreen)
-	MoveCursor(0, 0)
+	screen.Clear()
 }
 
 // Move the cursor n cells to up.
 func CursorUp(n int) {
-	fmt.Printf(Esc+"%dA", n)
+	Printf(Esc+"%dA", n)
 }
 
 // Move the cursor n cells to down.
 func CursorDown(n int) {
-	fmt.Printf(Esc+"%dB", n)
+	Printf(Esc+"%dB", n)
 }
 
 // Move the cursor n cells to right.
 func CursorForward(n int) {
-	fmt.Printf(Esc+"%dC", n)
+	Printf(Esc+"%dC", n)
 }
 
 // Move the cursor n cells to left.
 func CursorBack(n int) {
-	fmt.Printf(Esc+"%dD", n)
+	Printf(Esc+"%dD", n)
 }
 
 // Move cursor to beginning of the line n lines down.
 func CursorNextLine(n int) {
-	fmt.Printf(Esc+"%dE", n)
+	Printf(Esc+"%dE", n)
 }
 
 // Move cursor to beginning of the line n lines up.
 func CursorPreviousLine(n int) {
-	fmt.Printf(Esc+"%dF", n)
+	Printf(Esc+"%dF", n)
 }
 
 // Move cursor horizontally to x.
 func CursorHorizontalAbsolute(x int) {
-	fmt.Printf(Esc+"%dG", x)
+	Printf(Esc+"%dG", x)
 }
 
 // Show the cursor.
 func CursorShow() {
-	fmt.Print(Esc + "?25h")
+	screen.ShowCursor(true)
 }
 
 // Hide the cursor.
 func CursorHide() {
-	fmt.Print(Esc + "?25l")
+	screen.ShowCursor(false)
 }
 
 // Save the screen.
 func SaveScreen() {
-	fmt.Print(Esc + "?47h")
+	term.Write(Esc + "?47h")
+	term.Flush()
 }
 
 // Restore the saved screen.
 func RestoreScreen() {
-	fmt.Print(Esc + "?47l")
+	term.Write(Esc + "?47l")
+	term.Flush()
+	screen.Invalidate()
 }
 
 // stripAnsiEscapeCodes removes ANSI escape codes from a string
@@ -407,8 +428,8 @@ func GetTermSize() (int, int) {
 
 	} else {
 		// couldn't detect, so let's just set 80 x 25 to be safe
-		h := 80
-		w := 25
+		h := 25
+		w := 80
 
 		return h, w
 	}
@@ -423,37 +444,39 @@ func ReadAnsiFile(filePath string) (string, error) {
 	return string(content), nil
 }
 
-func displayAnsiFile(filePath string, localDisplay bool) {
+func displayAnsiFile(filePath string) {
 	content, err := ReadAnsiFile(filePath)
 	if err != nil {
 		log.Fatalf("Error reading file %s: %v", filePath, err)
 	}
 	ClearScreen()
-	PrintAnsi(content, 0, localDisplay)
+	PrintAnsi(content, 0)
+	screen.SaveBackground()
 }
 
 // Print ANSI art with a delay between lines
-func PrintAnsi(artContent string, delay int, localDisplay bool) { // Added localDisplay as an argument
+func PrintAnsi(artContent string, delay int) {
 	noSauce := TrimStringFromSauce(artContent) // strip off the SAUCE metadata
 	lines := strings.Split(noSauce, "\r\n")
 
 	for i, line := range lines {
-		if localDisplay {
-			// Convert line from CP437 to UTF-8
-			utf8Line, err := charmap.CodePage437.NewDecoder().String(line)
-			if err != nil {
-				fmt.Printf("Error converting to UTF-8: %v\n", err)
-				continue
-			}
-			line = utf8Line
+		// Art is stored as CP437; the renderer encodes it for the caller
+		utf8Line, err := charmap.CodePage437.NewDecoder().String(line)
+		if err != nil {
+			Printf("Error converting to UTF-8: %v\n", err)
+			continue
 		}
+		line = utf8Line
 
 		if i < len(lines)-1 && i != 24 { // Check for the 25th line (index 24)
-			fmt.Println(line) // Print with a newline
+			Println(line) // Print with a newline
 		} else {
-			fmt.Print(line) // Print without a newline (for the 25th line and the last line of the art)
+			Print(line) // Print without a newline (for the 25th line and the last line of the art)
+		}
+		if delay > 0 {
+			Flush()
+			time.Sleep(time.Duration(delay) * time.Millisecond)
 		}
-		time.Sleep(time.Duration(delay) * time.Millisecond)
 	}
 }
 
@@ -489,14 +512,15 @@ func PrintAnsiLoc(artfile string, x int, y int) {
 	s := bufio.NewScanner(strings.NewReader(string(noSauce)))
 
 	for s.Scan() {
-		fmt.Fprintf(os.Stdout, Esc+strconv.Itoa(yLoc)+";"+strconv.Itoa(x)+"f"+s.Text())
+		PrintStringLoc(s.Text(), x, yLoc)
 		yLoc++
 	}
 }
 
 // Print text at an X, Y location
 func PrintStringLoc(text string, x int, y int) {
-	fmt.Fprintf(os.Stdout, Esc+strconv.Itoa(y)+";"+strconv.Itoa(x)+"f"+text)
+	MoveCursor(x, y)
+	Print(text)
 }
 
 // CenterText horizontally centers some text
@@ -506,7 +530,7 @@ func CenterText(s string, w int) {
 		padding = 0
 	}
 	// Pad the left side of the string with spaces to center the text
-	fmt.Fprintf(os.Stdout, Cyan+"%[1]*s\n", -w, fmt.Sprintf("%[1]*s"+Reset, padding+len(s), s))
+	Printf(Cyan+"%[1]*s\n", -w, fmt.Sprintf("%[1]*s"+Reset, padding+len(s), s))
 }
 
 func centerTextAlt(text string, width int) string {
@@ -524,14 +548,14 @@ func AbsCenterText(s string, l int, c string) {
 	halfLen := l / 2
 	centerX := (modalW - modalW/2) - halfLen
 	MoveCursor(centerX, centerY)
-	fmt.Fprintf(os.Stdout, WhiteHi+c+s+Reset)
+	Print(WhiteHi + c + s + Reset)
 	result := Continue()
 	if result {
-		fmt.Fprintf(os.Stdout, BgCyan+CyanHi+" Yes"+Reset)
+		Print(BgCyan + CyanHi + " Yes" + Reset)
 		time.Sleep(1 * time.Second)
 	}
 	if !result {
-		fmt.Fprintf(os.Stdout, BgCyan+CyanHi+" No"+Reset)
+		Print(BgCyan + CyanHi + " No" + Reset)
 		time.Sleep(1 * time.Second)
 	}
 }
@@ -545,8 +569,8 @@ func AbsCenterArt(artfile string, l int) {
 	s := bufio.NewScanner(strings.NewReader(string(noSauce)))
 
 	for s.Scan() {
-		fmt.Fprintf(os.Stdout, Esc+strconv.Itoa(artY)+";"+strconv.Itoa(artX)+"f")
-		fmt.Println(s.Text())
+		MoveCursor(artX, artY)
+		Println(s.Text())
 		artY++
 	}
 }
@@ -569,7 +593,7 @@ func (tm *TimerManager) StartIdleTimer() {
 	}
 
 	tm.idleTimer = time.AfterFunc(tm.idleDuration, func() {
-		fmt.Println("\nYou've been idle for too long... exiting!")
+		Println("\nYou've been idle for too long... exiting!")
 		time.Sleep(2 * time.Second)
 		os.Exit(0)
 	})
@@ -595,7 +619,7 @@ func (tm *TimerManager) StartMaxTimer() {
 	}
 
 	tm.maxTimer = time.AfterFunc(tm.maxDuration, func() {
-		fmt.Println("\nMax time exceeded... exiting!")
+		Println("\nMax time exceeded... exiting!")
 		time.Sleep(2 * time.Second)
 		os.Exit(0)
 	})
@@ -628,7 +652,7 @@ func (tm *TimerManager) ResetIdleTimer() {
 	}
 
 	tm.idleTimer = time.AfterFunc(tm.idleDuration, func() {
-		fmt.Println("\nYou've been idle for too long... exiting!")
+		Println("\nYou've been idle for too long... exiting!")
 		time.Sleep(2 * time.Second)
 		os.Exit(0)
 	})
@@ -644,7 +668,7 @@ func (tm *TimerManager) ResetMaxTimer() {
 	}
 
 	tm.maxTimer = time.AfterFunc(tm.maxDuration, func() {
-		fmt.Println("\nMax time exceeded... exiting!")
+		Println("\nMax time exceeded... exiting!")
 		time.Sleep(2 * time.Second)
 		os.Exit(0)
 	})
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Character encodings a session can use, for both input and output
+const (
+	EncodingCP437 = iota
+	EncodingUTF8
+	EncodingPETSCII
+)
+
+// Encoding returns the character set the caller's terminal uses.
+func (u User) Encoding() int {
+	switch {
+	case u.Emulation == EmulationPETSCII:
+		return EncodingPETSCII
+	case u.LocalDisplay || u.UTF8:
+		return EncodingUTF8
+	}
+	return EncodingCP437
+}
+
+// inputDecoder turns the bytes the caller sends into keys: characters are
+// decoded from the session's encoding into whole runes, and escape
+// sequences into cursor and editing keys.
+type inputDecoder struct {
+	encoding int
+	pending  []byte // start of a UTF-8 sequence
+	keys     keyDecoder
+}
+
+func newInputDecoder(encoding int) *inputDecoder {
+	return &inputDecoder{encoding: encoding}
+}
+
+// Feed takes the next byte and returns any keys it completes.
+func (d *inputDecoder) Feed(b byte) []Key {
+	switch d.encoding {
+	case EncodingPETSCII:
+		if k, ok := petsciiKey(b); ok {
+			return []Key{k}
+		}
+		return d.keys.Feed(petsciiRune(b))
+	case EncodingCP437:
+		if b < 0x80 {
+			return d.keys.Feed(rune(b))
+		}
+		return d.keys.Feed(charmap.CodePage437.DecodeByte(b))
+	}
+
+	d.pending = append(d.pending, b)
+	var keys []Key
+	for len(d.pending) > 0 && utf8.FullRune(d.pending) {
+		r, size := utf8.DecodeRune(d.pending)
+		d.pending = d.pending[size:]
+		if r == utf8.RuneError && size == 1 {
+			continue // not UTF-8; drop the byte
+		}
+		keys = append(keys, d.keys.Feed(r)...)
+	}
+	if len(d.pending) == 0 {
+		d.pending = nil
+	}
+	return keys
+}
+
+// readWrapper reads the caller's input and sends it on as keys until the
+// game is done or the connection fails.
+func readWrapper(inputChan chan Key, errorChan chan error, doneChan chan bool, game *Game) {
+	decoder := newInputDecoder(game.User.Encoding())
+	buf := make([]byte, 256)
+	for {
+		select {
+		case <-doneChan:
+			return // Exit the goroutine if a done signal is received
+		default:
+			n, err := telnet.Read(buf)
+			if err != nil {
+				inputLog(LogLevelError, "SysOp", "Failed to read input")
+				errorChan <- err
+				return
+			}
+			if recording != nil {
+				recording.Input(buf[:n])
+			}
+			for _, b := range buf[:n] {
+				for _, key := range decoder.Feed(b) {
+					inputChan <- key
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// typed returns the keys for typing s.
+func typed(s string) []Key {
+	var keys []Key
+	for _, r := range s {
+		keys = append(keys, Key{Code: KeyRune, Rune: r})
+	}
+	return keys
+}
+
+func TestInputDecoder(t *testing.T) {
+	enter := Key{Code: KeyEnter}
+	tests := []struct {
+		name     string
+		encoding int
+		in       string
+		want     []Key
+	}{
+		{"plain ASCII", EncodingUTF8, "poop", typed("poop")},
+		{"UTF-8 accents", EncodingUTF8, "café ♥", typed("café ♥")},
+		{"invalid UTF-8 dropped", EncodingUTF8, "a\xffb", typed("ab")},
+		{"CP437 upper half", EncodingCP437, "caf\x82\x03", typed("café")},
+		{"CP437 box drawing", EncodingCP437, "\xc4\xb3", typed("─│")},
+		{"PETSCII letters swap case", EncodingPETSCII, "\x41\xc2", typed("aB")},
+		{"PETSCII cursor keys", EncodingPETSCII, "\x91\x11\x9d\x1d\x13", []Key{{Code: KeyUp}, {Code: KeyDown}, {Code: KeyLeft}, {Code: KeyRight}, {Code: KeyHome}}},
+		{"PETSCII delete", EncodingPETSCII, "\x14", []Key{{Code: KeyBackspace}}},
+		{"CR LF is one Enter", EncodingUTF8, "a\r\nb\n", []Key{{Code: KeyRune, Rune: 'a'}, enter, {Code: KeyRune, Rune: 'b'}, enter}},
+		{"arrow keys", EncodingCP437, "\x1b[A\x1b[B\x1bOC\x1b[D", []Key{{Code: KeyUp}, {Code: KeyDown}, {Code: KeyRight}, {Code: KeyLeft}}},
+		{"editing keys", EncodingUTF8, "\x1b[1~\x1b[4~\x1b[3~\x7f", []Key{{Code: KeyHome}, {Code: KeyEnd}, {Code: KeyDelete}, {Code: KeyBackspace}}},
+		{"unknown function key ignored", EncodingUTF8, "\x1b[15~x", typed("x")},
+		{"lone Esc", EncodingUTF8, "\x1bx", []Key{{Code: KeyEsc}, {Code: KeyRune, Rune: 'x'}}},
+		{"bracketed paste", EncodingUTF8, "\x1b[200~hi\x1b[201~", append(append([]Key{{Code: KeyPasteStart}}, typed("hi")...), Key{Code: KeyPasteEnd})},
+		{"SGR click", EncodingUTF8, "\x1b[<0;12;5M\x1b[<0;12;5m", []Key{{Code: KeyClick, X: 12, Y: 5}}},
+		{"SGR right click ignored", EncodingUTF8, "\x1b[<2;12;5M", nil},
+		{"X10 click", EncodingCP437, "\x1b[M ,%\x1b[M#,%", []Key{{Code: KeyClick, X: 12, Y: 5}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newInputDecoder(tt.encoding)
+			var got []Key
+			for i := 0; i < len(tt.in); i++ {
+				got = append(got, d.Feed(tt.in[i])...)
+			}
+			if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
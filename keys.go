@@ -0,0 +1,149 @@
+package main
+
+// KeyCode identifies a key the player pressed.
+type KeyCode int
+
+const (
+	KeyRune KeyCode = iota // a printable character, in Key.Rune
+	KeyEnter
+	KeyBackspace
+	KeyDelete
+	KeyLeft
+	KeyRight
+	KeyUp
+	KeyDown
+	KeyHome
+	KeyEnd
+	KeyEsc
+	KeyClick      // a left mouse click, at Key.X, Key.Y
+	KeyPasteStart // what follows was pasted, not typed
+	KeyPasteEnd
+)
+
+// Key is one keypress.
+type Key struct {
+	Code KeyCode
+	Rune rune
+	X, Y int
+}
+
+// keyDecoder folds the characters a terminal sends into keys, recognising
+// the VT100/ANSI sequences for the cursor and editing keys and the mouse
+// reports of xterm and SyncTERM.
+type keyDecoder struct {
+	seq    []rune
+	inEsc  bool
+	lastCR bool
+}
+
+// Feed takes the next character from the caller and returns any keys it
+// completes.
+func (d *keyDecoder) Feed(b rune) []Key {
+	if d.inEsc {
+		return d.escape(b)
+	}
+
+	wasCR := d.lastCR
+	d.lastCR = b == '\r'
+
+	switch b {
+	case 0x1b:
+		d.inEsc = true
+		d.seq = d.seq[:0]
+		return nil
+	case '\r':
+		return []Key{{Code: KeyEnter}}
+	case '\n':
+		if wasCR {
+			return nil // second half of CR LF
+		}
+		return []Key{{Code: KeyEnter}}
+	case '\b', 0x7f:
+		return []Key{{Code: KeyBackspace}}
+	case 0x01: // Ctrl-A
+		return []Key{{Code: KeyHome}}
+	case 0x05: // Ctrl-E
+		return []Key{{Code: KeyEnd}}
+	case 0x04: // Ctrl-D
+		return []Key{{Code: KeyDelete}}
+	}
+	if b < 0x20 || (b >= 0x80 && b < 0xa0) {
+		return nil
+	}
+	return []Key{{Code: KeyRune, Rune: b}}
+}
+
+// escape collects an escape sequence. Anything that isn't a sequence we
+// know is reported as Esc followed by the characters that came after it.
+func (d *keyDecoder) escape(b rune) []Key {
+	d.seq = append(d.seq, b)
+	first := d.seq[0]
+
+	if len(d.seq) > 2 && string(d.seq[:2]) == "[M" {
+		return d.x10Mouse()
+	}
+	if (first != '[' && first != 'O') || b >= 0x80 {
+		d.inEsc = false
+		return append([]Key{{Code: KeyEsc}}, d.Feed(b)...)
+	}
+	if len(d.seq) == 1 || (b >= '0' && b <= '9') || b == ';' || (b == '<' && len(d.seq) == 2) {
+		return nil // still collecting
+	}
+	if string(d.seq) == "[M" {
+		return nil // three more bytes to come
+	}
+	d.inEsc = false
+	if d.seq[1] == '<' {
+		return d.sgrMouse()
+	}
+
+	var code KeyCode
+	switch string(d.seq) {
+	case "[A", "OA":
+		code = KeyUp
+	case "[B", "OB":
+		code = KeyDown
+	case "[C", "OC":
+		code = KeyRight
+	case "[D", "OD":
+		code = KeyLeft
+	case "[H", "OH", "[1~", "[7~":
+		code = KeyHome
+	case "[F", "OF", "[K", "[4~", "[8~":
+		code = KeyEnd
+	case "[3~":
+		code = KeyDelete
+	case "[200~":
+		code = KeyPasteStart
+	case "[201~":
+		code = KeyPasteEnd
+	default:
+		return nil // some other function key; ignore it
+	}
+	return []Key{{Code: code}}
+}
+
+// sgrMouse decodes an SGR mouse report, ESC [ < button ; x ; y M (or m on
+// release). Only presses of the left button count.
+func (d *keyDecoder) sgrMouse() []Key {
+	end := d.seq[len(d.seq)-1]
+	params := parseParams(string(d.seq[2 : len(d.seq)-1]))
+	if end != 'M' || len(params) != 3 || params[0] != 0 {
+		return nil
+	}
+	return []Key{{Code: KeyClick, X: params[1], Y: params[2]}}
+}
+
+// x10Mouse decodes an old-style report, ESC [ M followed by the button, x
+// and y, each plus 32. Releases come as button 3 and are ignored.
+func (d *keyDecoder) x10Mouse() []Key {
+	if len(d.seq) < 5 {
+		return nil // still collecting
+	}
+	d.inEsc = false
+	button, x, y := d.seq[2]-32, int(d.seq[3]-32), int(d.seq[4]-32)
+	if button&3 != 0 || button&64 != 0 {
+		return nil
+	}
+	return []Key{{Code: KeyClick, X: x, Y: y}}
+}
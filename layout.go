@@ -0,0 +1,205 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Region is a rectangle of the screen that one kind of text is drawn in.
+// Clearing a region puts back the art underneath it.
+type Region struct {
+	X, Y int
+	W, H int
+	// FromBottom lines text up against the bottom row, so a one-line
+	// message sits where a longer one ends.
+	FromBottom bool
+}
+
+// Layout names the regions of one screen.
+type Layout struct {
+	Header  Region // the player's alias
+	Menu    Region // menu items
+	Status  Region // timer
+	Scene   Region // the picture above the message; zero if it can't move
+	Message Region // responses and warnings
+	Input   Region // the player's command line
+	Quit    Region // the QUIT label, for mouse clicks
+	Ticker  Region // notices from other nodes
+	Watch   Region // says the sysop is watching
+}
+
+// Clear restores the art under the region.
+func (r Region) Clear() {
+	if r.W <= 0 {
+		return
+	}
+	screen.Restore(r.X, r.Y, r.W, r.H)
+}
+
+// Contains reports whether (x,y) is inside the region.
+func (r Region) Contains(x, y int) bool {
+	return x >= r.X && x < r.X+r.W && y >= r.Y && y < r.Y+r.H
+}
+
+// Print clears the region and draws text in it, word-wrapped to its width.
+// Lines that don't fit are dropped.
+func (r Region) Print(text string) {
+	if r.W <= 0 {
+		return
+	}
+	r.Clear()
+	r.draw(wrapText(text, r.W))
+}
+
+// draw prints already wrapped lines over whatever is in the region.
+func (r Region) draw(lines []string) {
+	if len(lines) > r.H {
+		lines = lines[:r.H]
+	}
+	y := r.Y
+	if r.FromBottom {
+		y += r.H - len(lines)
+	}
+	// One PrintAt for all lines, so colors carry over from line to line
+	var sb strings.Builder
+	for i, line := range lines {
+		sb.WriteString(Esc + strconv.Itoa(y+i) + ";" + strconv.Itoa(r.X) + "H" + line)
+	}
+	screen.PrintAt(r.X, y, sb.String())
+}
+
+// artWidth is the width of the art shown on a w-column screen.
+func artWidth(w int) int {
+	if w < 80 {
+		return 40
+	}
+	return 80
+}
+
+// menuLayout places the main menu's text on main.ans (or main40.ans).
+func menuLayout(w, h int) Layout {
+	aw := artWidth(w)
+	input := Region{X: 7, Y: min(23, h), W: 18, H: 1}
+	menu := Region{X: 10, Y: 18, W: 22, H: 3}
+	if aw == 40 {
+		input.W = 33
+		menu = Region{X: 9, Y: 12, W: 11, H: 6}
+	}
+	return Layout{
+		Header:  Region{X: 4, Y: 2, W: 20, H: 1},
+		Menu:    menu,
+		Message: Region{X: 2, Y: min(24, h), W: aw - 2, H: 1},
+		Input:   input,
+		Ticker:  tickerRegion(aw, h),
+		Watch:   watchRegion(aw, h),
+	}
+}
+
+// playLayout places the play screen's text on start.ans (or start40.ans).
+func playLayout(w, h int) Layout {
+	aw := artWidth(w)
+	bottom := min(24, h)
+	quit := Region{X: 75, Y: 1, W: 4, H: 1}
+	if aw == 40 {
+		quit.X = 37
+	}
+	return Layout{
+		Header:  Region{X: 1, Y: 2, W: aw, H: 1},
+		Status:  Region{X: 1, Y: 1, W: aw - 6, H: 1},
+		Scene:   Region{X: 1, Y: 3, W: aw, H: bottom - 5},
+		Message: Region{X: 2, Y: bottom - 2, W: aw - 2, H: 2, FromBottom: true},
+		Input:   Region{X: 5, Y: bottom, W: aw - 5, H: 1},
+		Quit:    quit,
+		Ticker:  tickerRegion(aw, h),
+		Watch:   watchRegion(aw, h),
+	}
+}
+
+// tickerRegion is the bottom line, under the art, if the screen has one.
+// The watch label gets the end of it.
+func tickerRegion(aw, h int) Region {
+	if h < 25 {
+		return Region{}
+	}
+	return Region{X: 2, Y: 25, W: aw - 2 - watchRegion(aw, h).W, H: 1}
+}
+
+// watchRegion is the right end of the bottom line, if the session can be
+// watched and the player is to be told.
+func watchRegion(aw, h int) Region {
+	if h < 25 || spectating == nil || !showSpectators {
+		return Region{}
+	}
+	w := len(watchLabel(aw))
+	return Region{X: aw - w, Y: 25, W: w, H: 1}
+}
+
+// wrapText breaks text into lines of at most width visible characters,
+// at spaces where it can. ANSI color codes don't count towards the width
+// and newlines always break.
+func wrapText(text string, width int) []string {
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		line, lineLen := "", 0
+		for _, word := range strings.Split(para, " ") {
+			wordLen := visibleLen(word)
+			for wordLen > width {
+				// A word that can't fit on any line gets cut
+				if lineLen > 0 {
+					lines = append(lines, line)
+					line, lineLen = "", 0
+				}
+				head, tail := splitVisible(word, width)
+				lines = append(lines, head)
+				word, wordLen = tail, visibleLen(tail)
+			}
+			switch {
+			case lineLen == 0 && line == "":
+				line, lineLen = word, wordLen
+			case lineLen+1+wordLen <= width:
+				line += " " + word
+				lineLen += 1 + wordLen
+			default:
+				lines = append(lines, line)
+				line, lineLen = word, wordLen
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// visibleLen counts the characters of s that take up a cell.
+func visibleLen(s string) int {
+	return utf8.RuneCountInString(stripAnsiEscapeCodes(s))
+}
+
+// splitVisible cuts s after n visible characters, keeping escape codes
+// with the part they came in.
+func splitVisible(s string, n int) (string, string) {
+	count, inEsc := 0, false
+	for i, c := range s {
+		switch {
+		case inEsc:
+			if c >= 0x40 && c <= 0x7e && c != '[' {
+				inEsc = false
+			}
+		case c == 0x1b:
+			inEsc = true
+		default:
+			if count == n {
+				return s[:i], s[i:]
+			}
+			count++
+		}
+	}
+	return s, ""
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// leaderboardFile is where every node records finished runs, one JSON
+// object a line.
+const leaderboardFile = "scores.jsonl"
+
+// leaderboardRows is how many runs each table shows; three tables have to
+// fit on a 25-line screen.
+const leaderboardRows = 4
+
+// recordRun adds a finished run to the hall of fame.
+func recordRun(run *Run) error {
+	return appendJSON(dataPath(leaderboardFile), run)
+}
+
+// loadRuns reads back the runs that count for the hall of fame, leaving
+// out pasted ones.
+func loadRuns() ([]Run, error) {
+	var runs []Run
+	err := readJSONLines(dataPath(leaderboardFile), func(line []byte) error {
+		var run Run
+		if err := json.Unmarshal(line, &run); err != nil {
+			return nil
+		}
+		if !run.Pasted {
+			runs = append(runs, run)
+		}
+		return nil
+	})
+	return runs, err
+}
+
+// topRuns are the highest scores.
+func topRuns(runs []Run, n int) []Run {
+	top := append([]Run(nil), runs...)
+	sort.SliceStable(top, func(i, j int) bool { return top[i].Score > top[j].Score })
+	return firstRuns(top, n)
+}
+
+// fastestWins are the wins that took the least time.
+func fastestWins(runs []Run, n int) []Run {
+	var wins []Run
+	for _, run := range runs {
+		if run.Ending.Won() {
+			wins = append(wins, run)
+		}
+	}
+	sort.SliceStable(wins, func(i, j int) bool { return wins[i].Elapsed < wins[j].Elapsed })
+	return firstRuns(wins, n)
+}
+
+// mostAwards are the players holding the most awards, each by the first run
+// that got them there.
+func mostAwards(runs []Run, n int) []Run {
+	best := map[string]int{}
+	var players []Run
+	for _, run := range runs {
+		i, seen := best[run.Alias]
+		switch {
+		case !seen:
+			best[run.Alias] = len(players)
+			players = append(players, run)
+		case len(run.Awards) > len(players[i].Awards):
+			players[i] = run
+		}
+	}
+	sort.SliceStable(players, func(i, j int) bool { return len(players[i].Awards) > len(players[j].Awards) })
+	return firstRuns(players, n)
+}
+
+func firstRuns(runs []Run, n int) []Run {
+	if len(runs) > n {
+		return runs[:n]
+	}
+	return runs
+}
+
+// showScores prints the hall of fame: top runs, fastest wins and most
+// awards.
+func (g *Game) showScores() {
+	runs, err := loadRuns()
+	if err != nil && !os.IsNotExist(err) {
+		inputLog(LogLevelError, "SysOp", "Failed to read the leaderboard: "+err.Error())
+	}
+
+	wide := g.User.W >= 80
+	CenterText("HALL OF FAME", g.User.W)
+	Println("")
+	g.printScoreTable("Top Runs", "Ending", "Score", topRuns(runs, leaderboardRows), wide,
+		func(r Run) string { return string(r.Ending) },
+		func(r Run) string { return strconv.Itoa(r.Score) })
+	Println("")
+	g.printScoreTable("Fastest Wins", "Ending", "Time", fastestWins(runs, leaderboardRows), wide,
+		func(r Run) string { return string(r.Ending) },
+		func(r Run) string { return formatTimer(r.Elapsed) })
+	Println("")
+	g.printScoreTable("Most Awards", "Score", "Awards", mostAwards(runs, leaderboardRows), wide,
+		func(r Run) string { return strconv.Itoa(r.Score) },
+		func(r Run) string { return strconv.Itoa(len(r.Awards)) })
+}
+
+// printScoreTable prints one table of the hall of fame. Narrow screens
+// leave out the node and level columns.
+func (g *Game) printScoreTable(title, detail, value string, runs []Run, wide bool, detailOf, valueOf func(Run) string) {
+	row, aliasWidth := "%-3s%-14s%-13s%7s", 13
+	if wide {
+		row, aliasWidth = "%-4s%-20s%-6s%-20s%-14s%8s", 19
+	}
+	cells := func(rank, alias, node, level, d, v string) []interface{} {
+		if wide {
+			return []interface{}{rank, alias, node, level, d, v}
+		}
+		return []interface{}{rank, alias, d, v}
+	}
+
+	Printf(BgBlue+YellowHi+"%-*s"+Reset+"\n", len(fmt.Sprintf(row, cells("", "", "", "", "", "")...)), " "+title)
+	Printf(Cyan+row+Reset+"\n", cells("#", "Alias", "Node", "Level", detail, value)...)
+	if len(runs) == 0 {
+		Println(White + " Nobody yet." + Reset)
+		return
+	}
+	for i, run := range runs {
+		color := White
+		if run.Alias == g.User.Alias {
+			color = WhiteHi
+		}
+		Printf(color+row+Reset+"\n", cells(strconv.Itoa(i+1), clip(run.Alias, aliasWidth), strconv.Itoa(run.Node),
+			clip(run.Difficulty, 19), clip(detailOf(run), 12), valueOf(run))...)
+	}
+}
+
+// clip shortens s to fit a column of n characters.
+func clip(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRecordRunConcurrently(t *testing.T) {
+	useDataDir(t)
+	const nodes, runs = 8, 25
+	var wg sync.WaitGroup
+	for node := 1; node <= nodes; node++ {
+		wg.Add(1)
+		go func(node int) {
+			defer wg.Done()
+			for i := 0; i < runs; i++ {
+				// Long lines, so any that got mixed up would show
+				run := &Run{Alias: fmt.Sprint("node", node), Node: node, Score: i, Breakdown: make([]ScoreLine, 200)}
+				if err := recordRun(run); err != nil {
+					t.Error(err)
+				}
+			}
+		}(node)
+	}
+	wg.Wait()
+
+	got, err := loadRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != nodes*runs {
+		t.Errorf("read back %d runs, want %d", len(got), nodes*runs)
+	}
+}
+
+func TestLeaderboardTables(t *testing.T) {
+	runs := []Run{
+		{Alias: "ann", Ending: EndingToilet, Score: 900, Elapsed: 30 * time.Second, Awards: []string{"1"}},
+		{Alias: "bob", Ending: EndingPants, Score: 100, Elapsed: 5 * time.Second},
+		{Alias: "cat", Ending: EndingFloor, Score: 1200, Elapsed: 20 * time.Second, Awards: []string{"1", "2"}},
+		{Alias: "ann", Ending: EndingToilet, Score: 900, Elapsed: 25 * time.Second, Awards: []string{"1", "2", "3"}},
+	}
+	aliases := func(runs []Run) string {
+		var s []string
+		for _, run := range runs {
+			s = append(s, fmt.Sprint(run.Alias, ":", run.Elapsed))
+		}
+		return fmt.Sprint(s)
+	}
+	tests := []struct {
+		name string
+		got  []Run
+		want string
+	}{
+		{"top scores, ties in order", topRuns(runs, 3), "[cat:20s ann:30s ann:25s]"},
+		{"fastest wins leave out losses", fastestWins(runs, 4), "[cat:20s ann:25s ann:30s]"},
+		{"most awards, best run a player", mostAwards(runs, 4), "[ann:25s cat:20s bob:5s]"},
+		{"cut to n", topRuns(runs, 1), "[cat:20s]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := aliases(tt.got); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRunsLeavesOutPasted(t *testing.T) {
+	useDataDir(t)
+	for _, run := range []*Run{{Alias: "typed"}, {Alias: "pasted", Pasted: true}} {
+		if err := recordRun(run); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := loadRuns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Alias != "typed" {
+		t.Errorf("got %v, want just the typed run", got)
+	}
+}
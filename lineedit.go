@@ -0,0 +1,141 @@
+package main
+
+// maxHistory is how many earlier commands the line editor remembers.
+const maxHistory = 20
+
+// LineEditor is the command line used on the main menu and while playing.
+// It draws into an input region, never lets the text run past it, and
+// keeps a history of commands that Up and Down recall.
+type LineEditor struct {
+	Field   Region
+	Color   string // ANSI color the text is drawn in
+	buf     []rune
+	pos     int
+	history []string
+	histPos int    // index into history while browsing, len(history) when not
+	draft   []rune // what was being typed before browsing history
+}
+
+func newLineEditor() *LineEditor {
+	return &LineEditor{Color: BgBlue + YellowHi}
+}
+
+// max is the longest line that fits, leaving a cell for the cursor.
+func (e *LineEditor) max() int {
+	return e.Field.W - 1
+}
+
+// SetField moves the editor to a new input region and empties it.
+func (e *LineEditor) SetField(r Region) {
+	e.Field = r
+	e.Reset()
+}
+
+// Reset empties the line and redraws it.
+func (e *LineEditor) Reset() {
+	e.buf = nil
+	e.pos = 0
+	e.histPos = len(e.history)
+	e.draw()
+}
+
+// Empty reports whether nothing has been typed.
+func (e *LineEditor) Empty() bool {
+	return len(e.buf) == 0
+}
+
+// HandleKey edits the line. When the key is Enter it returns the finished
+// line and true, and starts a new empty one.
+func (e *LineEditor) HandleKey(k Key) (string, bool) {
+	switch k.Code {
+	case KeyEnter:
+		line := string(e.buf)
+		e.remember(line)
+		e.Reset()
+		return line, true
+	case KeyRune:
+		if len(e.buf) >= e.max() {
+			return "", false
+		}
+		e.buf = append(e.buf, 0)
+		copy(e.buf[e.pos+1:], e.buf[e.pos:])
+		e.buf[e.pos] = k.Rune
+		e.pos++
+	case KeyBackspace:
+		if e.pos == 0 {
+			return "", false
+		}
+		e.buf = append(e.buf[:e.pos-1], e.buf[e.pos:]...)
+		e.pos--
+	case KeyDelete:
+		if e.pos == len(e.buf) {
+			return "", false
+		}
+		e.buf = append(e.buf[:e.pos], e.buf[e.pos+1:]...)
+	case KeyLeft:
+		if e.pos > 0 {
+			e.pos--
+		}
+	case KeyRight:
+		if e.pos < len(e.buf) {
+			e.pos++
+		}
+	case KeyHome:
+		e.pos = 0
+	case KeyEnd:
+		e.pos = len(e.buf)
+	case KeyUp:
+		if e.histPos == 0 {
+			return "", false
+		}
+		if e.histPos == len(e.history) {
+			e.draft = e.buf
+		}
+		e.histPos--
+		e.recall([]rune(e.history[e.histPos]))
+	case KeyDown:
+		if e.histPos == len(e.history) {
+			return "", false
+		}
+		e.histPos++
+		if e.histPos == len(e.history) {
+			e.recall(e.draft)
+		} else {
+			e.recall([]rune(e.history[e.histPos]))
+		}
+	default:
+		return "", false
+	}
+	e.draw()
+	return "", false
+}
+
+// recall puts an earlier line in the editor, cut to fit the field.
+func (e *LineEditor) recall(line []rune) {
+	if len(line) > e.max() {
+		line = line[:e.max()]
+	}
+	e.buf = append([]rune(nil), line...)
+	e.pos = len(e.buf)
+}
+
+func (e *LineEditor) remember(line string) {
+	if line == "" || (len(e.history) > 0 && e.history[len(e.history)-1] == line) {
+		return
+	}
+	e.history = append(e.history, line)
+	if len(e.history) > maxHistory {
+		e.history = e.history[1:]
+	}
+}
+
+// draw shows the line in the field and puts the cursor where editing
+// happens.
+func (e *LineEditor) draw() {
+	if e.Field.W <= 0 {
+		return
+	}
+	e.Field.Clear()
+	screen.PrintAt(e.Field.X, e.Field.Y, e.Color+string(e.buf)+Reset)
+	MoveCursor(e.Field.X+e.pos, e.Field.Y)
+}
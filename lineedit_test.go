@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLineEditor(t *testing.T) {
+	k := func(c KeyCode) Key { return Key{Code: c} }
+	keys := func(parts ...interface{}) []Key {
+		var out []Key
+		for _, p := range parts {
+			switch p := p.(type) {
+			case string:
+				out = append(out, typed(p)...)
+			case KeyCode:
+				out = append(out, k(p))
+			}
+		}
+		return out
+	}
+	tests := []struct {
+		name    string
+		history []string
+		keys    []Key
+		want    string
+		wantPos int
+	}{
+		{"typing", nil, keys("shit"), "shit", 4},
+		{"insert mid-line", nil, keys("sit", KeyLeft, KeyLeft, "h"), "shit", 2},
+		{"backspace", nil, keys("farts", KeyBackspace), "fart", 4},
+		{"backspace at the start", nil, keys("fart", KeyHome, KeyBackspace), "fart", 0},
+		{"delete", nil, keys("xfart", KeyHome, KeyDelete), "fart", 0},
+		{"delete at the end", nil, keys("fart", KeyDelete), "fart", 4},
+		{"home and end", nil, keys("art", KeyHome, "f", KeyEnd, "s"), "farts", 5},
+		{"cursor stops at the ends", nil, keys("ab", KeyRight, KeyLeft, KeyLeft, KeyLeft, "x"), "xab", 1},
+		{"cut off at the field", nil, keys("0123456789abc"), "012345678", 9},
+		{"up recalls the last", []string{"shit", "fart"}, keys(KeyUp), "fart", 4},
+		{"up twice", []string{"shit", "fart"}, keys(KeyUp, KeyUp, KeyUp), "shit", 4},
+		{"down back to the draft", []string{"shit", "fart"}, keys("pu", KeyUp, KeyUp, KeyDown, KeyDown), "pu", 2},
+		{"recall is cut to fit", []string{"0123456789abc"}, keys(KeyUp), "012345678", 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newLineEditor()
+			e.SetField(Region{X: 1, Y: 1, W: 10, H: 1})
+			e.history = tt.history
+			e.Reset()
+			for _, key := range tt.keys {
+				if _, done := e.HandleKey(key); done {
+					t.Fatalf("line finished on %v", key)
+				}
+			}
+			if string(e.buf) != tt.want || e.pos != tt.wantPos {
+				t.Errorf("got %q at %d, want %q at %d", string(e.buf), e.pos, tt.want, tt.wantPos)
+			}
+		})
+	}
+}
+
+func TestLineEditorHistory(t *testing.T) {
+	e := newLineEditor()
+	e.SetField(Region{X: 1, Y: 1, W: 10, H: 1})
+	for _, line := range []string{"shit", "", "shit", "fart"} {
+		for _, key := range append(typed(line), Key{Code: KeyEnter}) {
+			if got, done := e.HandleKey(key); done && got != line {
+				t.Errorf("Enter gave %q, want %q", got, line)
+			}
+		}
+	}
+	// Blank lines and repeats aren't remembered
+	if got := fmt.Sprint(e.history); got != "[shit fart]" {
+		t.Errorf("history %s, want [shit fart]", got)
+	}
+	for i := 0; i < maxHistory+5; i++ {
+		e.remember(string(rune('a' + i)))
+	}
+	if len(e.history) != maxHistory {
+		t.Errorf("remembered %d lines, want %d", len(e.history), maxHistory)
+	}
+	if e.history[0] != "f" {
+		t.Errorf("oldest line %q, want %q", e.history[0], "f")
+	}
+	if !e.Empty() {
+		t.Errorf("line not empty after Enter")
+	}
+}
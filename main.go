@@ -24,6 +24,12 @@ const (
 	stateCredits
 	stateIntro
 	stateAwards
+	stateScores
+	stateNews
+	stateWho
+	stateLobby
+	stateRaceResult
+	stateDaily
 	LogLevelInput = iota
 	LogLevelWarning
 	LogLevelError
@@ -107,6 +113,7 @@ type User struct {
 	ModalH       int
 	ModalW       int
 	LocalDisplay bool
+	UTF8         bool // caller's terminal sends and shows UTF-8
 	Awards       map[string]bool
 }
 
@@ -117,6 +124,25 @@ type Game struct {
 	AwardedAwards   map[string]bool
 	mutex           sync.Mutex
 	UserInputBuffer []string
+	layout          Layout // regions of the screen being shown
+	panel           *MessagePanel
+	editor          *LineEditor
+	menu            *Menu // main menu, while it's showing
+	mouse           bool  // ask the caller's terminal to report clicks
+	pastePolicy     int   // what to do with pasted text
+	pasting         bool  // between the terminal's paste markers
+	events          []timedEvent
+	timerShown      time.Duration // what the timer display reads
+	timerColor      string
+	level           int  // index into difficulties
+	lastRun         *Run // the round just finished
+	profile         Profile
+	noticeUntil     time.Time // when to clear the notice ticker
+	status          NodeStatus
+	statusMutex     sync.Mutex // the heartbeat sends status from its own goroutine
+	race            *Race      // the race being run, if it is one
+	challenge       *Challenge // the daily challenge being played, if it is one
+	ghost           *Run       // the personal best being raced, if there is one
 }
 
 type GameState struct {
@@ -129,12 +155,14 @@ type GameState struct {
 	PillTimer     time.Duration
 	RemainingTime time.Duration
 	stopTime      bool
-	cursX         int
-	cursY         int
 	AppState      int
-	DoneChan      chan bool
 	LastAppState  int
 	OnMainMenu    bool
+	Pasted        bool      // the player pasted during this run
+	Difficulty    string    // name of the level the run is played on
+	Commands      int       // commands typed this run
+	Started       time.Time // when play began
+	Timeline      []Step    // what the player got done, and when
 }
 
 func inputLog(level int, userAlias string, message string) {
@@ -178,36 +206,17 @@ func disableRawMode(originalState *unix.Termios) error {
 	return nil
 }
 
-func readWrapper(inputChan chan byte, errorChan chan error, doneChan chan bool, game *Game) {
-	for {
-		select {
-		case <-doneChan:
-			return // Exit the goroutine if a done signal is received
-		default:
-			buf := make([]byte, 1) // Read one byte at a time
-			n, err := os.Stdin.Read(buf)
-			if err != nil {
-				inputLog(LogLevelError, "SysOp", "Failed to read input")
-				errorChan <- err
-				return
-			}
-			if n > 0 {
-				inputChan <- buf[0] // Send the byte to the channel
-			}
-		}
-	}
-}
-
 func (g *Game) setupGameEnvironment() {
 	// This function should set up the game environment (clear screen, display art, etc.)
 	ClearScreen()
-	displayAnsiFile(ArtFileDir+"main.ans", g.User.LocalDisplay)
-	MoveCursor(4, 2)
-	fmt.Printf(BgMagenta+YellowHi+"%s"+WhiteHi+":"+Reset, g.User.Alias)
-	// MoveCursor(6, 24)
+	displayAnsiFile(artPath("main"))
+	g.setLayout(menuLayout(g.User.W, g.User.H))
+	g.layout.Header.Print(BgMagenta + YellowHi + g.User.Alias + WhiteHi + ":" + Reset)
+	g.menu = newMainMenu(g.layout)
+	g.menu.Draw()
 }
 
-func (g *Game) updateGameEnvironment(inputChan chan byte) {
+func (g *Game) updateGameEnvironment(inputChan chan Key) {
 	// Only update the environment if the game state has changed
 	if g.GameState.AppState != g.GameState.LastAppState {
 		ClearScreen()
@@ -216,25 +225,24 @@ func (g *Game) updateGameEnvironment(inputChan chan byte) {
 
 		case stateMainMenu:
 			g.GameState.OnMainMenu = true
-			displayAnsiFile(ArtFileDir+"main.ans", g.User.LocalDisplay)
-			MoveCursor(4, 2)
-			fmt.Printf(BgMagenta+YellowHi+"%s"+WhiteHi+":"+Reset, g.User.Alias)
-			g.GameState.cursX, g.GameState.cursY = 7, 23
-			MoveCursor(7, 23)
-			fmt.Print(Reset)
+			displayAnsiFile(artPath("main"))
+			g.setLayout(menuLayout(g.User.W, g.User.H))
+			g.layout.Header.Print(BgMagenta + YellowHi + g.User.Alias + WhiteHi + ":" + Reset)
+			g.menu = newMainMenu(g.layout)
+			g.menu.Draw()
+			g.resetInput()
+			Print(Reset)
 
 		case statePlaying:
 			g.GameState.OnMainMenu = false
-			displayAnsiFile(ArtFileDir+"start.ans", g.User.LocalDisplay)
-			MoveCursor(2, 23)
-			fmt.Print(BgBlue + CyanHi + "You need to take a shit. Bad." + Reset)
-			MoveCursor(5, 24)
-			fmt.Print(YellowHi)
-			g.GameState.cursX, g.GameState.cursY = 5, 24
-			fmt.Print(Reset)
+			displayAnsiFile(artPath("start"))
+			g.setLayout(playLayout(g.User.W, g.User.H))
+			g.message(BgBlue + CyanHi + "You need to take a shit. Bad." + Reset)
+			g.resetInput()
+			Print(Reset)
 
 		case stateGameOver:
-			fmt.Print(Reset)
+			Print(Reset)
 			g.GameState.OnMainMenu = false
 			g.GameState.Farts = 0
 			// Clear the input buffer here
@@ -247,17 +255,22 @@ func (g *Game) updateGameEnvironment(inputChan chan byte) {
 			for _, award := range awards {
 				if g.User.Awards[award.ID] {
 					if !awardsEarned {
-						fmt.Println("Awards earned by", g.User.Alias+":")
+						Println("Awards earned by", g.User.Alias+":")
 						awardsEarned = true
 					}
 					awardName := getAwardNameByID(award.ID)
-					fmt.Println(awardName)
+					Println(awardName)
 				}
 			}
 
 			if !awardsEarned {
-				fmt.Println("You shit your pants!")
+				if g.lastRun != nil {
+					Println(endingMessages[g.lastRun.Ending])
+				} else {
+					Println("You shit your pants!")
+				}
 			}
+			g.showScore()
 
 			// Pause for a keypress
 			g.readSingleKeyPress(inputChan, stateMainMenu)
@@ -267,57 +280,67 @@ func (g *Game) updateGameEnvironment(inputChan chan byte) {
 			g.GameState.OnMainMenu = false
 			ClearScreen()
 			CursorHide()
-			displayAnsiFile(ArtFileDir+"intro.ans", g.User.LocalDisplay)
+			displayAnsiFile(artPath("intro"))
 
-			PrintStringLoc("3", 40, 19)
+			centerX := g.User.W / 2
+			PrintStringLoc("3", centerX, 19)
 			DelayedAction(1*time.Second, func() {
-				PrintStringLoc("2", 40, 19)
+				PrintStringLoc("2", centerX, 19)
 			})
 
 			DelayedAction(1*time.Second, func() {
-				PrintStringLoc("1", 40, 19)
+				PrintStringLoc("1", centerX, 19)
 			})
 
 			DelayedAction(1*time.Second, func() {
-				PrintStringLoc("GO!", 39, 19)
+				PrintStringLoc("GO!", centerX-1, 19)
 			})
 
 			DelayedAction(1*time.Second, func() {
-				fmt.Print(Reset)
+				Print(Reset)
 				CursorShow()
 			})
 
 			// ... other cases ...
 		}
 		g.GameState.LastAppState = g.GameState.AppState
+		g.updateStatus()
 	}
 }
 
-func (g *Game) cleanupGameEnvironment() {
-	// This function should clean up the game environment when the game ends or quits
+// setLayout switches to the regions of a newly drawn screen.
+func (g *Game) setLayout(l Layout) {
+	g.layout = l
+	g.panel = newMessagePanel(l)
 }
 
-func (g *Game) cleanupGame() {
-	// Close channels used during the game if they are no longer needed
-	if g.GameState.DoneChan != nil {
-		close(g.GameState.DoneChan)
-		g.GameState.DoneChan = make(chan bool) // Reinitialize for future use
-	}
+// message shows text in the current screen's message panel.
+func (g *Game) message(text string) {
+	g.panel.Show(text, "")
 }
 
-func DelayedAction(duration time.Duration, action func()) {
-	done := make(chan bool)
+// messageMoral shows text with a red moral line under it.
+func (g *Game) messageMoral(text, moral string) {
+	g.panel.Show(text, moral)
+}
+
+// resetInput empties the command line and puts the cursor at its start.
+func (g *Game) resetInput() {
+	g.editor.SetField(g.layout.Input)
+}
 
-	go func() {
-		time.Sleep(duration)
-		action() // Execute the specified action
-		done <- true
-	}()
+func (g *Game) cleanupGameEnvironment() {
+	// This function should clean up the game environment when the game ends or quits
+}
 
-	<-done // Wait for the goroutine to complete
+func DelayedAction(duration time.Duration, action func()) {
+	Flush() // Show what's been drawn before waiting
+	clock.Sleep(duration)
+	action() // Execute the specified action
+	Flush()
 }
 
-func (g *Game) handleMainMenuInput(input string, inputChan chan byte, errorChan chan error, doneChan chan bool) {
+func (g *Game) handleMainMenuInput(input string, inputChan chan Key, errorChan chan error, doneChan chan bool) {
 	if input != "" {
 		inputLog(LogLevelInput, g.User.Alias, input)
 	}
@@ -338,8 +361,7 @@ func (g *Game) handleMainMenuInput(input string, inputChan chan byte, errorChan
 	case "quit", "exit":
 		g.GameState.AppState = stateQuit
 		CursorHide()
-		MoveCursor(7, 23)
-		fmt.Println(BgBlue + RedHi + "Exiting the game..." + Reset)
+		g.message(BgBlue + RedHi + "Exiting the game..." + Reset)
 		DelayedAction(2*time.Second, func() {
 			CursorShow()
 		})
@@ -353,23 +375,94 @@ func (g *Game) handleMainMenuInput(input string, inputChan chan byte, errorChan
 		// Check if the user has earned any awards
 		if len(g.User.Awards) > 0 {
 			// Display the user's awards
-			fmt.Println("Awards earned by", g.User.Alias+":")
+			Println("Awards earned by", g.User.Alias+":")
 			for awardID, earned := range g.User.Awards {
 				if earned {
 					// Print the name of the award
 					awardName := getAwardNameByID(awardID)
-					fmt.Println("- " + awardName)
+					Println("- " + awardName)
 				}
 			}
 		} else {
 			// User has no awards
-			fmt.Println("No awards earned yet by", g.User.Alias)
+			Println("No awards earned yet by", g.User.Alias)
 		}
 
 		// Wait for a single keypress
 		g.readSingleKeyPress(inputChan, stateMainMenu)
 		g.GameState.AppState = stateMainMenu
-		MoveCursor(7, 23)
+		CursorShow()
+	case "difficulty":
+		g.nextDifficulty()
+	case "news":
+		g.GameState.AppState = stateNews
+		g.updateGameEnvironment(inputChan)
+		CursorHide()
+
+		g.showNews()
+
+		g.readSingleKeyPress(inputChan, stateMainMenu)
+		CursorShow()
+	case "daily":
+		g.playDaily(inputChan, errorChan, doneChan)
+	case "race":
+		g.findRace(inputChan, errorChan, doneChan)
+	case "who":
+		g.GameState.AppState = stateWho
+		g.updateGameEnvironment(inputChan)
+		CursorHide()
+
+		g.showWho()
+
+		g.readSingleKeyPress(inputChan, stateMainMenu)
+		CursorShow()
+	case "notices":
+		g.toggleNotices()
+	case "scores":
+		g.GameState.AppState = stateScores
+		g.updateGameEnvironment(inputChan)
+		CursorHide()
+
+		g.showScores()
+
+		g.readSingleKeyPress(inputChan, stateMainMenu)
+		CursorShow()
+	case "credits":
+		g.GameState.AppState = stateCredits
+		g.updateGameEnvironment(inputChan)
+		CursorHide()
+
+		Println("Original flash game by Decade Studios")
+		Println("(now Cellar Door Games, www.cellardoorgames.com)")
+		Println("")
+		Println("Programming, art & design: Kenny Lee")
+		Println("Audio & design: Teddy Lee")
+		Println("")
+		Println("PICO-8 remake by Princess Choochoo")
+
+		g.readSingleKeyPress(inputChan, stateMainMenu)
+		CursorShow()
+	case "help":
+		g.GameState.AppState = stateHelp
+		g.updateGameEnvironment(inputChan)
+		CursorHide()
+
+		Println("Instructions:")
+		Println("- to start, type \"play\", or P and Enter")
+		Println("- to view achievements, press A or type \"awards\"")
+		Println("- to change how hard it is, type \"difficulty\", or D and Enter")
+		Println("- to see the hall of fame, type \"scores\"")
+		Println("- to read the daily happenings, type \"news\"")
+		Println("- to see who else is playing, type \"who\"")
+		Println("- to race another caller for the toilet, type \"race\"")
+		Println("- to take on today's challenge, type \"daily\"")
+		Println("- to turn notices from other nodes off or on, type \"notices\"")
+		Println("- pick from the menu with the arrow keys and Enter")
+		Println("")
+		Println("Goal:")
+		Println("- don't shit your pants")
+
+		g.readSingleKeyPress(inputChan, stateMainMenu)
 		CursorShow()
 	default:
 		// Check if the input matches any verb from poopVerbs
@@ -386,24 +479,23 @@ func (g *Game) handleMainMenuInput(input string, inputChan chan byte, errorChan
 
 		// If no matching verb is found, handle it as an invalid choice
 		CursorHide()
-		MoveCursor(7, 23)
-		fmt.Print(BgBlue + RedHi + "Invalid choice!" + Reset)
+		g.message(BgBlue + RedHi + "Invalid choice!" + Reset)
 
 		DelayedAction(1*time.Second, func() {
-			MoveCursor(7, 23)
-			fmt.Print(BgBlue + RedHi + "                       " + Reset)
-			MoveCursor(7, 23)
-			g.GameState.cursX, g.GameState.cursY = 7, 23
+			g.panel.Clear()
+			g.resetInput()
 			g.updateGameEnvironment(inputChan)
 			CursorShow()
 		})
 	}
 }
 
-func (g *Game) handleGameplayInput(input string, stopChan chan bool, inputChan chan byte) {
-	if input != "" {
-		inputLog(LogLevelInput, g.User.Alias, input)
+func (g *Game) handleGameplayInput(input string, inputChan chan Key) {
+	if input == "" {
+		return
 	}
+	inputLog(LogLevelInput, g.User.Alias, input)
+	g.GameState.Commands++
 
 	// Check if the first word (verbs) matches any of the "Main Words" from the mappings
 	if mainWord, ok := verbMappings[input]; ok {
@@ -415,33 +507,19 @@ func (g *Game) handleGameplayInput(input string, stopChan chan bool, inputChan c
 
 	switch inputWords[0] { // Check the first word of the input
 	case "quit":
-		// Similar to "shit," protect any shared resources with a mutex
-		g.mutex.Lock()
-		defer g.mutex.Unlock()
-		// Send a signal to stop the timer
-		stopChan <- true
-		safeClose(stopChan) // Safely close the channel
-		g.cleanupGame()     // Perform any necessary cleanup
+		g.finishRun(EndingQuit)
 		g.GameState.AppState = stateGameOver
 		g.updateGameEnvironment(inputChan)
 		return
 	case "fart":
 		if len(inputWords) == 1 {
 			// If only "fart" is entered as a single word, go to GameOver and return to MainMenu
-			g.mutex.Lock()
-			defer g.mutex.Unlock()
-			stopChan <- true
-			safeClose(stopChan)
 			g.processFartLoseCommand(inputChan)
 			g.GameState.AppState = stateMainMenu
 			g.updateGameEnvironment(inputChan)
 			return
-		} else if g.GameState.Farts >= 3 {
-			// User farted three or more times, trigger a failure
-			g.mutex.Lock()
-			defer g.mutex.Unlock()
-			stopChan <- true
-			safeClose(stopChan)
+		} else if g.GameState.Farts >= g.difficulty().AllowedFarts {
+			// User farted more than the difficulty allows, trigger a failure
 			g.processFartLoseCommand(inputChan)
 			g.GameState.AppState = stateMainMenu
 			g.updateGameEnvironment(inputChan)
@@ -457,27 +535,18 @@ func (g *Game) handleGameplayInput(input string, stopChan chan bool, inputChan c
 				}
 
 				CursorHide()
-				MoveCursor(4, 23)
-				fmt.Print(BgBlue + CyanHi + " 																	  " + Reset)
-				MoveCursor(2, 23)
-				fmt.Print(BgBlue + CyanHi + "You farted lightly. Relief!                                          " + Reset)
-				MoveCursor(4, 24)
-				fmt.Print(BgBlue + RedHi + "                                                                      " + Reset)
-				g.GameState.cursX, g.GameState.cursY = 5, 24
-				MoveCursor(g.GameState.cursX, g.GameState.cursY)
+				g.message(BgBlue + CyanHi + "You farted lightly. Relief!" + Reset)
+				g.resetInput()
 				CursorShow()
-				g.GameState.RemainingTime += 60 * time.Second // Add 60 seconds to the timer
-				g.GameState.Farts++                           // Increment the number of farts
+				g.GameState.RemainingTime += g.difficulty().FartBonus // Add the fart bonus to the timer
+				g.GameState.Farts++                                   // Increment the number of farts
+				g.step("farted lightly")
 
-				if g.GameState.Farts == 2 {
-					// Display a warning for the second fart
+				if g.GameState.Farts == g.difficulty().AllowedFarts {
+					// Display a warning for the last allowed fart
 					CursorHide()
-					MoveCursor(2, 23)
-					fmt.Print(BlueHi + RedHi + "                                                       " + Reset)
-					MoveCursor(2, 23)
-					fmt.Print(BgBlue + RedHi + "You farted already. A second one will stain your pants." + Reset)
+					g.messageMoral(BgBlue+CyanHi+"You farted already."+Reset, "Another one will stain your pants.")
 					CursorShow()
-					g.GameState.Farts++ // Increment the number of farts
 				}
 
 				g.GameState.AppState = statePlaying
@@ -486,15 +555,8 @@ func (g *Game) handleGameplayInput(input string, stopChan chan bool, inputChan c
 			} else {
 				// If the second word is not in the adverb list, handle it as an invalid choice
 				CursorHide()
-				MoveCursor(2, 23)
-				fmt.Print(BgBlue + RedHi + "                                                                           " + Reset)
-				MoveCursor(2, 23)
-				fmt.Print(BgBlue + RedHi + "Invalid choice!" + Reset)
-
-				MoveCursor(6, 24)
-				fmt.Print(BgBlue + RedHi + "                                                                        " + Reset)
-				g.GameState.cursX, g.GameState.cursY = 6, 24
-				MoveCursor(g.GameState.cursX, g.GameState.cursY)
+				g.message(BgBlue + RedHi + "Invalid choice!" + Reset)
+				g.resetInput()
 				CursorShow()
 				return
 			}
@@ -505,10 +567,6 @@ func (g *Game) handleGameplayInput(input string, stopChan chan bool, inputChan c
 		// Check if the input matches any verb from poopVerbs
 		for _, verb := range poopVerbs {
 			if input == verb {
-				g.mutex.Lock()
-				defer g.mutex.Unlock()
-				stopChan <- true
-				safeClose(stopChan) // Safely close the channel
 				g.processShitCommand(inputChan)
 				g.GameState.AppState = stateMainMenu
 				g.updateGameEnvironment(inputChan)
@@ -516,17 +574,14 @@ func (g *Game) handleGameplayInput(input string, stopChan chan bool, inputChan c
 			}
 		}
 
+		if g.roomCommand(inputWords) {
+			return
+		}
+
 		// If no matching verb is found, handle it as an invalid choice
 		CursorHide()
-		MoveCursor(2, 23)
-		fmt.Print(BgBlue + RedHi + "                                                                             " + Reset)
-		MoveCursor(2, 23)
-		fmt.Print(BgBlue + RedHi + "I don't know how to " + Reset + BgBlue + CyanHi + input + Reset)
-
-		MoveCursor(5, 24)
-		fmt.Print(BgBlue + RedHi + "                                                                          " + Reset)
-		MoveCursor(5, 24)
-		g.GameState.cursX, g.GameState.cursY = 5, 24
+		g.message(BgBlue + RedHi + "I don't know how to " + Reset + BgBlue + CyanHi + input + Reset)
+		g.resetInput()
 		CursorShow()
 	}
 
@@ -541,12 +596,13 @@ func containsWordFromList(word string, list []string) bool {
 	return false
 }
 
-func (g *Game) readSingleKeyPress(inputChan chan byte, nextState int) {
+func (g *Game) readSingleKeyPress(inputChan chan Key, nextState int) {
 	// Wait for a single keypress
 
 	CursorHide()
-	MoveCursor(0, 23)
-	CenterText("Press a Key to Continue", 80)
+	MoveCursor(0, g.User.H-2)
+	CenterText("Press a Key to Continue", term.Width())
+	Flush()
 
 	<-inputChan
 
@@ -556,12 +612,13 @@ func (g *Game) readSingleKeyPress(inputChan chan byte, nextState int) {
 	g.updateGameEnvironment(inputChan)
 }
 
-func (g *Game) pause(inputChan chan byte) {
+func (g *Game) pause(inputChan chan Key) {
 	// Wait for a single keypress
 
 	CursorHide()
-	MoveCursor(0, 23)
-	CenterText("Press a Key to Continue", 80)
+	MoveCursor(0, g.User.H-2)
+	CenterText("Press a Key to Continue", term.Width())
+	Flush()
 
 	<-inputChan
 
@@ -570,10 +627,13 @@ func (g *Game) pause(inputChan chan byte) {
 
 }
 
-func (g *Game) processFartLoseCommand(inputChan chan byte) {
+func (g *Game) processFartLoseCommand(inputChan chan Key) {
+	// Pants on or off, a fart that goes too far ends in them
+	g.finishRun(EndingPants)
 	CursorHide()
 	ClearScreen()
-	fmt.Println("You farted too hard and shit your pants!")
+	Println("You farted too hard and shit your pants!")
+	g.showScore()
 	g.pause(inputChan)
 
 	// Display user's awarded awards
@@ -585,27 +645,33 @@ func (g *Game) processFartLoseCommand(inputChan chan byte) {
 	// Check if the user has earned any awards
 	if len(g.User.Awards) > 0 {
 		// Display the user's awards
-		fmt.Println("Awards earned by", g.User.Alias+":")
+		Println("Awards earned by", g.User.Alias+":")
 		for awardID, earned := range g.User.Awards {
 			if earned {
 				// Print the name of the award
 				awardName := getAwardNameByID(awardID)
-				fmt.Println("- " + awardName)
+				Println("- " + awardName)
 			}
 		}
 	} else {
 		// User has no awards
-		fmt.Println("No awards earned yet by", g.User.Alias)
+		Println("No awards earned yet by", g.User.Alias)
 	}
 
 	// Wait for a single keypress
 	g.readSingleKeyPress(inputChan, stateMainMenu)
 	g.GameState.AppState = stateMainMenu
-	MoveCursor(7, 23)
 	CursorShow()
 }
 
-func (g *Game) processShitCommand(inputChan chan byte) {
+func (g *Game) processShitCommand(inputChan chan Key) {
+	playing := g.GameState.AppState == statePlaying
+	var run *Run
+	if playing {
+		// The round ends when they let go, not once they've read their awards
+		run = g.stopRun(g.shitEnding())
+	}
+
 	// Check and grant any awards
 	g.UserInputBuffer = append(g.UserInputBuffer, "shit")
 	g.checkAndGrantAwards(inputChan)
@@ -616,16 +682,21 @@ func (g *Game) processShitCommand(inputChan chan byte) {
 	for _, award := range awards {
 		if g.User.Awards[award.ID] {
 			if !awardsEarned {
-				fmt.Println("Awards earned by", g.User.Alias+":")
+				Println("Awards earned by", g.User.Alias+":")
 				awardsEarned = true
 			}
 			awardName := getAwardNameByID(award.ID)
-			fmt.Println(awardName)
+			Println(awardName)
 		}
 	}
 
 	if !awardsEarned {
-		fmt.Println("You shit your pants!")
+		Println("You shit your pants!")
+	}
+
+	if playing {
+		g.saveRun(run)
+		g.showScore()
 	}
 
 	// Pause for a keypress
@@ -637,76 +708,81 @@ func (g *Game) processShitCommand(inputChan chan byte) {
 	g.updateGameEnvironment(inputChan)
 }
 
-func (g *Game) startGame(inputChan chan byte, errorChan chan error, doneChan chan bool) {
+// startGame plays one round. Keys, clock ticks and scheduled events are all
+// handled here, one at a time, so nothing else changes the game or draws
+// while a round is on.
+func (g *Game) startGame(inputChan chan Key, errorChan chan error, doneChan chan bool) {
 	g.GameState.AppState = stateIntro
 	g.updateGameEnvironment(inputChan)
 
 	g.GameState.AppState = statePlaying
 	g.updateGameEnvironment(inputChan)
 
-	stopChan := make(chan bool)
-
-	g.GameState.RemainingTime = time.Second * 40 // Set the initial timer value
-	g.GameState.Farts = 0                        // Set Farts to inital value
-
-	go g.timer(stopChan, inputChan)
-
-	var r []rune
-	for {
-		fmt.Print(BgBlue + YellowHi)
+	g.GameState.RemainingTime = g.difficulty().StartTime // Set the initial timer value
+	g.GameState.Farts = 0                                // Set Farts to inital value
+	g.GameState.Pasted = false
+	g.GameState.Difficulty = g.difficulty().Name
+	g.GameState.Commands = 0
+	g.GameState.Started = clock.Now()
+	g.GameState.Timeline = nil
+	g.resetRoom()
+	g.findGhost()
+	g.lastRun = nil
+	g.events = nil
+	g.scheduleUrgency()
+	g.scheduleChallenge()
+	g.timerShown = g.GameState.RemainingTime
+	g.drawTimer()
+
+	ticker := clock.NewTicker(time.Second)
+	defer ticker.Stop()
+	frames := clock.NewTicker(timerFrame)
+	defer frames.Stop()
+	notices := clock.NewTicker(noticePoll)
+	defer notices.Stop()
+
+	for g.GameState.AppState == statePlaying {
+		Flush()
 		select {
-		case char := <-inputChan:
-			runeChar := rune(char) // Convert byte to rune
-			if runeChar == '\r' || runeChar == '\n' {
-
-				input := sanitizeInput(strings.ToLower(string(r)))
-				r = nil // Reset buffer
-				fmt.Print(Reset)
+		case key := <-inputChan:
+			g.playKey(key, inputChan)
 
-				// fmt.Println("\nInput received:", input)
-				g.handleGameplayInput(input, stopChan, inputChan) // Handle input with stopChan
-
-				// Check if the state has changed to MainMenu, if so, break the loop
-				if g.GameState.AppState == stateMainMenu {
-					safeClose(stopChan) // Safely close the stop channel
-					return
-				}
-				// Debug: Print UserInputBuffer at position 0, 25
-				MoveCursor(1, 2)
-				fmt.Print(BgBlue + YellowHi)
-				fmt.Printf("Buffer: %v\n", g.UserInputBuffer)
-				fmt.Print(Reset)
-			} else if runeChar == '\b' || runeChar == 127 {
-				if len(r) > 0 {
-					r = r[:len(r)-1] // Remove the last character from the buffer
-					// Handle backspace for the terminal: Move cursor back, print space, move cursor back again
-					fmt.Print("\b \b")
-					g.GameState.cursX--
-				}
+		case <-ticker.Ticks():
+			g.tick(inputChan)
 
-			} else {
-				fmt.Print(string(runeChar)) // Print character as it's typed
-				r = append(r, runeChar)
-				g.GameState.cursX++
-				MoveCursor(g.GameState.cursX, g.GameState.cursY)
+		case <-frames.Ticks():
+			g.animateTimer()
 
-			}
+		case <-notices.Ticks():
+			g.pollNotices()
+			g.drawWatching()
 
 		case err := <-errorChan:
-			fmt.Println("Error reading input:", err)
+			Println("Error reading input:", err)
 			log.Print("Error reading input:", err)
-			safeClose(stopChan) // Safely close the stop channel
 			// Cleanup and exit the game
 			return
-
-		}
-		// Check if the state has changed to MainMenu, if so, break the loop
-		if g.GameState.AppState != statePlaying {
-			return
 		}
-		fmt.Print(Reset)
+	}
+}
 
+// playKey handles a key during play.
+func (g *Game) playKey(key Key, inputChan chan Key) {
+	if !g.pasteKey(key) {
+		return
+	}
+	var line string
+	var done bool
+	if key.Code == KeyClick && g.layout.Quit.Contains(key.X, key.Y) {
+		line, done = "quit", true // clicking QUIT is the same as typing it
+	} else {
+		line, done = g.editor.HandleKey(key)
 	}
+	if !done {
+		return
+	}
+
+	g.handleGameplayInput(sanitizeInput(strings.ToLower(line)), inputChan)
 }
 
 func sanitizeInput(input string) string {
@@ -716,76 +792,98 @@ func sanitizeInput(input string) string {
 	// Remove any invalid characters or perform additional sanitization if needed
 	// For example, you can use a regular expression to allow only specific characters
 
-	// Example: Allow only letters, digits, and spaces
-	validChars := regexp.MustCompile(`[^a-zA-Z0-9 ]`)
+	// Allow only letters, digits, and spaces, in any language
+	validChars := regexp.MustCompile(`[^\p{L}\p{N} ]`)
 	input = validChars.ReplaceAllString(input, "")
 
 	return input
 }
 
-// Safe channel close utility function
-func safeClose(ch chan bool) {
-	select {
-	case <-ch:
-		// Channel already closed
-	default:
-		close(ch)
-	}
-}
-
-func (g *Game) run(inputChan chan byte, errorChan chan error, doneChan chan bool) {
+func (g *Game) run(inputChan chan Key, errorChan chan error, doneChan chan bool) {
 	// Set up the game environment
 	g.GameState.AppState = stateMainMenu
 	g.setupGameEnvironment()
 	defer g.cleanupGameEnvironment()
 
-	MoveCursor(7, 23) // Start from position 4 on the next line
-
-	fmt.Print(BgBlue + YellowHi)
+	g.resetInput()
 
 	// Enable raw mode
 	originalState, err := enableRawMode()
-	if err != nil {
+	if err == nil {
+		defer disableRawMode(originalState) // Restore terminal state at the end
+	} else if !telnet.Active() {
+		// On a telnet socket there's no terminal to set up; the telnet
+		// options already turned off the caller's line mode and echo.
 		inputLog(LogLevelError, "SysOp", "Failed to enable raw mode")
 		fmt.Fprintln(os.Stderr, "Failed to enable raw mode:", err)
 		os.Exit(1)
 	}
-	defer disableRawMode(originalState) // Restore terminal state at the end
 
-	var r []rune
+	if g.pastePolicy != pasteAccept {
+		term.BracketedPaste(true)
+		defer func() {
+			term.BracketedPaste(false)
+			term.Flush()
+		}()
+	}
+
+	// Clicks are optional; without reports everything works from the keys
+	if g.mouse {
+		term.MouseReporting(true)
+		defer func() {
+			term.MouseReporting(false)
+			term.Flush()
+		}()
+	}
+
+	// Other nodes can see this one and reach it through its spool
+	g.updateStatus()
+	defer g.leaveSpool()
+	defer g.leaveNodes()
+	defer g.keepAlive()()
+	notices := clock.NewTicker(noticePoll)
+	defer notices.Stop()
+
 	for g.GameState.AppState != stateQuit {
-		fmt.Print(BgBlue + YellowHi)
+		Flush()
 		select {
-		case char := <-inputChan:
-			runeChar := rune(char)
-			key := string(runeChar)
-
-			if char == '\r' || char == '\n' {
-				input := sanitizeInput(strings.ToLower(string(r)))
-				r = nil          // Reset buffer
-				fmt.Print(Reset) // Move to the next line
+		case key := <-inputChan:
+			if !g.pasteKey(key) {
+				break
+			}
+			// With nothing typed, keys drive the menu's hotkeys and
+			// highlight bar; otherwise they edit a typed command. A
+			// hotkey that might be the start of a typed command goes to
+			// the editor, and picks its item if Enter follows it alone.
+			if g.GameState.AppState == stateMainMenu && g.editor.Empty() {
+				if command, used := g.menu.HandleKey(key); used {
+					if command != "" {
+						g.handleMainMenuInput(command, inputChan, errorChan, doneChan)
+					}
+					break
+				}
+			}
+			if line, done := g.editor.HandleKey(key); done {
+				input := sanitizeInput(strings.ToLower(line))
 				if g.GameState.AppState == stateMainMenu {
+					if command, ok := g.menu.Pick(input); ok {
+						input = command
+					}
 					g.handleMainMenuInput(input, inputChan, errorChan, doneChan)
 				} else if g.GameState.AppState == statePlaying {
-					g.handleGameplayInput(input, nil, inputChan) // Pass nil if stopChan is not needed or not available
-				}
-			} else if char == '\b' || char == 127 {
-				if len(r) > 0 {
-					r = r[:len(r)-1]   // Remove the last character from the buffer
-					fmt.Print("\b \b") // Handle backspace: move cursor back, print space, move cursor back again
-					g.GameState.cursX--
+					g.handleGameplayInput(input, inputChan)
 				}
-			} else {
-				// Regular character input
-				fmt.Print(key) // Print character as it's typed
-				r = append(r, rune(char))
-				g.GameState.cursX++
-				MoveCursor(g.GameState.cursX, g.GameState.cursY)
 			}
 
+		case <-notices.Ticks():
+			if g.GameState.AppState == stateMainMenu {
+				g.pollNotices()
+			}
+			g.drawWatching()
+
 		case err := <-errorChan:
 			inputLog(LogLevelError, "SysOp", "Error reading input")
-			fmt.Println("Error reading input:", err)
+			Println("Error reading input:", err)
 			return
 		}
 
@@ -793,10 +891,11 @@ func (g *Game) run(inputChan chan byte, errorChan chan error, doneChan chan bool
 		g.updateGameEnvironment(inputChan)
 	}
 
+	Flush()
 	close(doneChan) // Signal all goroutines to stop
 }
 
-func initializeGame(localDisplay bool, dropPath string) *Game {
+func initializeGame(localDisplay bool, dropPath string, petscii, utf8 bool) *Game {
 	// Initialize the User with either default values or based on command-line arguments
 
 	var user User
@@ -813,6 +912,10 @@ func initializeGame(localDisplay bool, dropPath string) *Game {
 			ModalW:       80,
 			LocalDisplay: localDisplay,
 		}
+		if petscii {
+			user.Emulation = EmulationPETSCII
+			user.H, user.W = 25, 40
+		}
 	} else {
 		// Check for required --path argument if --local is not set
 		if dropPath == "" {
@@ -820,7 +923,31 @@ func initializeGame(localDisplay bool, dropPath string) *Game {
 			fmt.Fprintln(os.Stderr, "missing required -path argument")
 			os.Exit(2)
 		}
-		user = Initialize(dropPath)
+		user = Initialize(dropPath, petscii)
+	}
+	user.UTF8 = utf8
+	if recordSessions {
+		r, err := startRecording(user)
+		if err != nil {
+			inputLog(LogLevelError, "SysOp", "Failed to start recording: "+err.Error())
+		} else if r != nil {
+			recording = r
+			inputLog(LogLevelWarning, "SysOp", fmt.Sprintf("Recording the session, keystrokes and all, to %s for %d days",
+				r.f.Name(), recordingDays))
+		}
+	}
+	if spectateSessions {
+		m, err := startMirror(user)
+		if err != nil {
+			inputLog(LogLevelError, "SysOp", "Failed to open the spectate socket: "+err.Error())
+		} else if m != nil {
+			spectating = m
+		}
+	}
+	term = newRenderer(user)
+	screen = NewScreen(term)
+	if spectating != nil {
+		go spectating.serve()
 	}
 
 	// Initialize GameState with default or initial values
@@ -833,10 +960,7 @@ func initializeGame(localDisplay bool, dropPath string) *Game {
 		Pills:         false,
 		PillTimer:     0,
 		stopTime:      false,
-		cursX:         7,
-		cursY:         23,
 		AppState:      stateMainMenu,
-		DoneChan:      make(chan bool),
 		LastAppState:  stateMainMenu,
 		OnMainMenu:    true,
 		RemainingTime: 40*time.Second + 1*time.Second, // Set the initial timer value
@@ -851,6 +975,7 @@ func initializeGame(localDisplay bool, dropPath string) *Game {
 		GameState:     gameState,
 		Awards:        awards,
 		AwardedAwards: make(map[string]bool),
+		editor:        newLineEditor(),
 	}
 
 	// Initialize User.Awards map
@@ -860,6 +985,29 @@ func initializeGame(localDisplay bool, dropPath string) *Game {
 }
 
 func main() {
+	// Sysop commands run instead of the door
+	if len(os.Args) > 1 && os.Args[1] == "bulletin" {
+		if err := runBulletin(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "bulletin:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		if err := runReplay(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "replay:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "spectate" {
+		if err := runSpectate(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, "spectate:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Open or create the log file in append mode
 	file, err := os.OpenFile("game.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
@@ -872,18 +1020,61 @@ func main() {
 	// Define the flags
 	localDisplayPtr := flag.Bool("local", false, "use local UTF-8 display instead of CP437")
 	pathPtr := flag.String("path", "", "path to door32.sys file (optional if --local is set)")
+	petsciiPtr := flag.Bool("petscii", false, "use PETSCII output for Commodore 64 callers")
+	utf8Ptr := flag.Bool("utf8", false, "caller's terminal uses UTF-8 instead of CP437")
+	mousePtr := flag.Bool("mouse", true, "ask ANSI terminals to report mouse clicks")
+	pastePtr := flag.String("paste", "flag", "what to do with pasted input: accept, reject, or flag the run")
+	dataPtr := flag.String("data", dataDir, "directory for files shared by all nodes, like the hall of fame")
+	newsDaysPtr := flag.Int("news-days", newsDays, "days of daily news to keep")
+	recordPtr := flag.Bool("record", recordSessions, "record each session, including what the caller types, as an asciicast under the data directory")
+	recordingDaysPtr := flag.Int("recording-days", recordingDays, "days to keep session recordings before they are deleted")
+	spectatePtr := flag.Bool("spectate", spectateSessions, "let the sysop watch sessions with dsyp spectate")
+	showSpectatorsPtr := flag.Bool("show-spectators", showSpectators, "tell the player when the sysop is watching")
+	urgencyPtr := flag.String("urgency", "", "JSON file of the warnings to give as time runs out, instead of the built-in ones")
+	telnetPtr := flag.Bool("telnet", false, "stdin is a raw telnet connection (assumed when stdin is a socket)")
 
 	// Parse the flags
 	flag.Parse()
 
 	// Use the flag values
 	localDisplay := *localDisplayPtr
+	dataDir = *dataPtr
+	newsDays = *newsDaysPtr
+	pruneNews(clock.Now())
+	recordSessions = *recordPtr
+	recordingDays = *recordingDaysPtr
+	pruneRecordings(clock.Now())
+	spectateSessions = *spectatePtr
+	showSpectators = *showSpectatorsPtr
+	pastePolicy, err := parsePastePolicy(*pastePtr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *urgencyPtr != "" {
+		stages, err := loadUrgency(*urgencyPtr)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		urgencyStages = stages
+	}
+
+	// Negotiate before sizing the screen, so NAWS can tell us how big it is
+	// and the cursor query never reads the caller's negotiation
+	if *telnetPtr || onSocket() {
+		telnet.Start()
+		telnet.AwaitSize(2 * time.Second)
+	}
 
 	// Initialize the game
-	game := initializeGame(localDisplay, *pathPtr)
+	game := initializeGame(localDisplay, *pathPtr, *petsciiPtr, *utf8Ptr)
+	game.mouse = *mousePtr && game.User.Emulation != EmulationPETSCII
+	game.pastePolicy = pastePolicy
+	game.profile = loadProfile(game.User.Alias)
 
 	// Input channels
-	inputChan := make(chan byte)
+	inputChan := make(chan Key)
 	errorChan := make(chan error)
 	doneChan := make(chan bool)
 
@@ -892,5 +1083,11 @@ func main() {
 
 	// Start the game
 	game.run(inputChan, errorChan, doneChan)
+	if recording != nil {
+		recording.Close()
+	}
+	if spectating != nil {
+		spectating.Close()
+	}
 
 }
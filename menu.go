@@ -0,0 +1,162 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MenuItem is one choice on a menu: what it says, the key that picks it
+// and the command it stands for.
+type MenuItem struct {
+	Label   string // the hotkey is its first letter
+	Command string
+}
+
+// Menu is a highlight-bar menu drawn in a region. Items are laid out in
+// columns, top to bottom; the arrow keys move the bar, Enter picks the
+// highlighted item, and an item's first letter or a click on it picks it
+// straight away. A letter that could start one of the Typed commands
+// waits to be typed out instead, and picks its item only if Enter
+// follows it alone.
+type Menu struct {
+	Items    []MenuItem
+	Typed    []string // commands that can be typed as well as picked
+	Field    Region
+	Selected int
+	Normal   string // color of the labels
+	Hotkey   string // color of each label's first letter
+	Bar      string // color of the highlighted label
+}
+
+// mainMenuCommands are the commands the main menu takes typed out.
+var mainMenuCommands = []string{
+	"play", "difficulty", "awards", "credits", "help", "quit", "exit",
+	"daily", "race", "who", "notices", "news", "scores",
+}
+
+// newMainMenu returns the main menu for a screen laid out as l.
+func newMainMenu(l Layout) *Menu {
+	m := &Menu{
+		Items: []MenuItem{
+			{"Play", "play"},
+			{"Difficulty", "difficulty"},
+			{"Awards", "awards"},
+			{"Credits", "credits"},
+			{"Help", "help"},
+			{"Quit", "quit"},
+		},
+		Typed:  append(append([]string{}, mainMenuCommands...), poopVerbs...),
+		Field:  l.Menu,
+		Normal: BgMagenta + CyanHi,
+		Hotkey: BgMagenta + YellowHi,
+		Bar:    BgCyan + WhiteHi,
+	}
+	if l.Menu.W < 20 {
+		m.Normal, m.Hotkey = BgBlack+CyanHi, BgBlack+YellowHi
+	}
+	return m
+}
+
+// HandleKey moves the bar or picks an item. It returns the command picked,
+// if any, and whether the menu used the key at all.
+func (m *Menu) HandleKey(k Key) (string, bool) {
+	switch k.Code {
+	case KeyUp, KeyLeft:
+		m.Selected = (m.Selected + len(m.Items) - 1) % len(m.Items)
+	case KeyDown, KeyRight:
+		m.Selected = (m.Selected + 1) % len(m.Items)
+	case KeyEnter:
+		return m.Items[m.Selected].Command, true
+	case KeyClick:
+		width := m.columnWidth() - 1
+		for i, item := range m.Items {
+			x, y := m.itemAt(i)
+			if k.Y == y && k.X >= x && k.X < x+width {
+				m.Selected = i
+				m.Draw()
+				return item.Command, true
+			}
+		}
+		return "", false
+	case KeyRune:
+		i := m.hotkey(k.Rune)
+		if i < 0 || m.typing(k.Rune, m.Items[i].Command) {
+			return "", false
+		}
+		m.Selected = i
+		m.Draw()
+		return m.Items[i].Command, true
+	default:
+		return "", false
+	}
+	m.Draw()
+	return "", true
+}
+
+// Pick returns the command for a typed line that is just an item's
+// hotkey, for the letters that wait for Enter.
+func (m *Menu) Pick(line string) (string, bool) {
+	r := []rune(line)
+	if len(r) != 1 {
+		return "", false
+	}
+	if i := m.hotkey(r[0]); i >= 0 {
+		m.Selected = i
+		m.Draw()
+		return m.Items[i].Command, true
+	}
+	return "", false
+}
+
+// hotkey returns the item whose first letter r is, or -1.
+func (m *Menu) hotkey(r rune) int {
+	for i, item := range m.Items {
+		if unicode.ToLower(r) == unicode.ToLower([]rune(item.Label)[0]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// typing reports whether r could be the start of a typed command other
+// than the one its hotkey picks.
+func (m *Menu) typing(r rune, command string) bool {
+	for _, typed := range m.Typed {
+		if typed != command && strings.HasPrefix(typed, string(unicode.ToLower(r))) {
+			return true
+		}
+	}
+	return false
+}
+
+// columnWidth is how wide each column of items is.
+func (m *Menu) columnWidth() int {
+	cols := (len(m.Items) + m.Field.H - 1) / m.Field.H
+	return m.Field.W / cols
+}
+
+// itemAt returns where item i is drawn.
+func (m *Menu) itemAt(i int) (int, int) {
+	return m.Field.X + i/m.Field.H*m.columnWidth(), m.Field.Y + i%m.Field.H
+}
+
+// Draw shows the items, with the bar on the selected one.
+func (m *Menu) Draw() {
+	if m.Field.W <= 0 || m.Field.H <= 0 {
+		return
+	}
+	m.Field.Clear()
+	width := m.columnWidth() - 1
+	for i, item := range m.Items {
+		x, y := m.itemAt(i)
+		label := item.Label
+		if len(label) < width {
+			label += strings.Repeat(" ", width-len(label))
+		}
+		if i == m.Selected {
+			screen.PrintAt(x, y, m.Bar+label+Reset)
+		} else {
+			screen.PrintAt(x, y, m.Hotkey+label[:1]+m.Normal+label[1:]+Reset)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import "testing"
+
+func TestMenuHotkeys(t *testing.T) {
+	tests := []struct {
+		name string
+		key  rune
+		want string // command picked at once, if any
+		used bool
+	}{
+		{"awards at once", 'a', "awards", true},
+		{"upper case too", 'H', "help", true},
+		{"quit at once", 'q', "quit", true},
+		{"p could be play or poop", 'p', "", false},
+		{"d could be daily or dump", 'D', "", false},
+		{"c could be crap", 'c', "", false},
+		{"not a hotkey", 'z', "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMainMenu(menuLayout(80, 25))
+			got, used := m.HandleKey(Key{Code: KeyRune, Rune: tt.key})
+			if got != tt.want || used != tt.used {
+				t.Errorf("got %q, %v, want %q, %v", got, used, tt.want, tt.used)
+			}
+		})
+	}
+}
+
+func TestMenuPick(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+		ok   bool
+	}{
+		{"p", "play", true},
+		{"D", "difficulty", true},
+		{"c", "credits", true},
+		{"play", "", false},
+		{"daily", "", false},
+		{"z", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			m := newMainMenu(menuLayout(80, 25))
+			got, ok := m.Pick(tt.line)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Pick(%q) = %q, %v, want %q, %v", tt.line, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestMenuBar(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []KeyCode
+		want string
+	}{
+		{"first item", nil, "play"},
+		{"down one", []KeyCode{KeyDown}, "difficulty"},
+		{"up wraps round", []KeyCode{KeyUp}, "quit"},
+		{"right moves on", []KeyCode{KeyRight, KeyRight}, "awards"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMainMenu(menuLayout(80, 25))
+			for _, k := range tt.keys {
+				m.HandleKey(Key{Code: k})
+			}
+			if got, _ := m.HandleKey(Key{Code: KeyEnter}); got != tt.want {
+				t.Errorf("Enter picked %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// newsDir holds a file of happenings for each day, under dataDir.
+const newsDir = "news"
+
+// newsDays is how many days of news are kept.
+var newsDays = 7
+
+// newsShown is how many items of a day the news screen has room for.
+const newsShown = 8
+
+// NewsItem is one line of the daily news.
+type NewsItem struct {
+	When time.Time
+	Text string
+}
+
+// newsPath returns the news file for the day of t.
+func newsPath(t time.Time) string {
+	return dataPath(filepath.Join(newsDir, t.Format("2006-01-02")+".jsonl"))
+}
+
+// postNews adds an item to today's news.
+func postNews(text string) {
+	now := clock.Now()
+	if err := appendJSON(newsPath(now), NewsItem{When: now, Text: text}); err != nil {
+		inputLog(LogLevelError, "SysOp", "Failed to post news: "+err.Error())
+	}
+}
+
+// readNews returns the news of the day of t, oldest first.
+func readNews(t time.Time) []NewsItem {
+	path := newsPath(t)
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	var items []NewsItem
+	err := readJSONLines(path, func(line []byte) error {
+		var item NewsItem
+		if json.Unmarshal(line, &item) == nil {
+			items = append(items, item)
+		}
+		return nil
+	})
+	if err != nil {
+		inputLog(LogLevelError, "SysOp", "Failed to read news: "+err.Error())
+	}
+	return items
+}
+
+// pruneNews deletes news files more than newsDays old.
+func pruneNews(now time.Time) {
+	files, err := filepath.Glob(dataPath(filepath.Join(newsDir, "*.jsonl")))
+	if err != nil {
+		return
+	}
+	oldest := now.AddDate(0, 0, -newsDays).Format("2006-01-02")
+	for _, file := range files {
+		day := strings.TrimSuffix(filepath.Base(file), ".jsonl")
+		if day < oldest {
+			os.Remove(file)
+		}
+	}
+}
+
+// reportRun posts the news a finished run makes, judged against the runs
+// before it.
+func reportRun(run *Run, past []Run) {
+	if run.Pasted {
+		return
+	}
+	if hasAward(*run, "10") {
+		switch {
+		case len(shitKings(past)) == 0:
+			postNews(run.Alias + " is the first ever Shit King! All hail the crown.")
+		case !isShitKing(run.Alias, past):
+			postNews(run.Alias + " has been crowned Shit King.")
+		}
+	}
+	if run.PantsOff && !run.Ending.Won() && run.Ending != EndingQuit {
+		postNews(run.Alias + " went down with their pants off.")
+	}
+	if run.Ending.Won() {
+		if fastest := fastestWins(past, 1); len(fastest) == 0 || run.Elapsed < fastest[0].Elapsed {
+			postNews(fmt.Sprintf("%s set a record, winning in %s on %s.", run.Alias, formatTimer(run.Elapsed), run.Difficulty))
+		}
+	}
+	if top := topRuns(past, 1); len(top) > 0 && run.Score > top[0].Score {
+		postNews(fmt.Sprintf("%s took the high score with %d points.", run.Alias, run.Score))
+	}
+}
+
+// isShitKing reports whether alias was crowned in one of runs.
+func isShitKing(alias string, runs []Run) bool {
+	for _, king := range shitKings(runs) {
+		if king.Alias == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// showNews prints today's and yesterday's news.
+func (g *Game) showNews() {
+	now := clock.Now()
+	CenterText("DAILY HAPPENINGS", g.User.W)
+	Println("")
+	g.printNews("Today", readNews(now))
+	Println("")
+	g.printNews("Yesterday", readNews(now.AddDate(0, 0, -1)))
+}
+
+// printNews prints a day's news, latest last, keeping to what fits.
+func (g *Game) printNews(title string, items []NewsItem) {
+	Println(BgBlue + YellowHi + " " + title + " " + Reset)
+	if len(items) == 0 {
+		Println(White + " Nothing happened." + Reset)
+		return
+	}
+	if len(items) > newsShown {
+		items = items[len(items)-newsShown:]
+	}
+	for _, item := range items {
+		Println(Cyan + item.When.Format("15:04") + " " + WhiteHi + clip(item.Text, g.User.W-7) + Reset)
+	}
+}
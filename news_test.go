@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPantsOffNews(t *testing.T) {
+	tests := []struct {
+		name   string
+		ending Ending
+		pants  bool
+		want   bool
+	}{
+		{"farted too hard, pants off", EndingPants, false, true},
+		{"farted too hard, pants on", EndingPants, true, false},
+		{"quit with pants off", EndingQuit, false, false},
+		{"won with pants off", EndingToilet, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useManualClock(t)
+			g := newTestGame(t)
+			g.GameState.Pants = tt.pants
+			g.GameState.Started = clock.Now().Add(-10 * time.Second)
+			g.finishRun(tt.ending)
+
+			got := false
+			for _, item := range readNews(clock.Now()) {
+				if item.Text == "Tester went down with their pants off." {
+					got = true
+				}
+			}
+			if got != tt.want {
+				t.Errorf("pants-off news posted %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
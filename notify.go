@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// spoolDir holds a directory for each node in the door, under dataDir.
+// Other nodes drop notices into it and the node picks them up.
+const spoolDir = "spool"
+
+// aliveFile is touched by its node every heartbeat. A node that stops touching
+// it has dropped, and its directory gets swept away.
+const aliveFile = "alive"
+
+const (
+	noticePoll  = 2 * time.Second  // how often a node checks for notices
+	noticeShown = 10 * time.Second // how long a notice stays on the ticker
+	nodeStale   = time.Minute      // how long before a quiet node is gone
+)
+
+// Notice is a message from one node to the others. Plain notices go on
+// the ticker; the others are races being arranged.
+type Notice struct {
+	Kind  string
+	Alias string
+	Node  int
+	Text  string
+	Race  string // race being arranged
+	Level int    // difficulty it's run on
+	When  time.Time
+}
+
+// endingNotices are what the other nodes hear about each ending.
+var endingNotices = map[Ending]string{
+	EndingToilet:      "made it to the toilet!",
+	EndingFloor:       "shit on the floor!",
+	EndingPillsWorked: "was saved by the pills!",
+	EndingToiletPants: "shit their pants on the toilet!",
+	EndingPants:       "just shit their pants!",
+	EndingQuit:        "gave up.",
+}
+
+// nodeSpool returns the directory node picks its notices up from.
+func nodeSpool(node int) string {
+	return dataPath(filepath.Join(spoolDir, strconv.Itoa(node)))
+}
+
+// joinSpool opens this node's spool, or tells the others it's still here.
+func (g *Game) joinSpool() {
+	dir := nodeSpool(g.User.NodeNum)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		inputLog(LogLevelError, "SysOp", "Failed to open the node spool: "+err.Error())
+		return
+	}
+	alive := filepath.Join(dir, aliveFile)
+	now := clock.Now()
+	if err := os.Chtimes(alive, now, now); os.IsNotExist(err) {
+		if f, err := os.Create(alive); err == nil {
+			f.Close()
+			os.Chtimes(alive, now, now)
+		}
+	}
+}
+
+// leaveSpool closes this node's spool, dropping whatever is in it.
+func (g *Game) leaveSpool() {
+	os.RemoveAll(nodeSpool(g.User.NodeNum))
+}
+
+// broadcast sends text to every other node in the door, and sweeps away
+// the spools of nodes that have dropped.
+func (g *Game) broadcast(text string) {
+	dirs, err := filepath.Glob(dataPath(filepath.Join(spoolDir, "*")))
+	if err != nil {
+		return
+	}
+	notice := g.newNotice("", text)
+	for _, dir := range dirs {
+		if dir == nodeSpool(g.User.NodeNum) {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(dir, aliveFile))
+		if err != nil || notice.When.Sub(info.ModTime()) > nodeStale {
+			os.RemoveAll(dir)
+			continue
+		}
+		writeNotice(dir, notice)
+	}
+}
+
+// newNotice starts a notice from this node.
+func (g *Game) newNotice(kind, text string) Notice {
+	return Notice{Kind: kind, Alias: g.User.Alias, Node: g.User.NodeNum, Text: text, When: clock.Now()}
+}
+
+// sendNotice sends a notice to one node.
+func sendNotice(node int, notice Notice) {
+	writeNotice(nodeSpool(node), notice)
+}
+
+// writeNotice drops a notice into a spool. It's written aside and renamed
+// in, so the node never reads half of it.
+func writeNotice(dir string, notice Notice) {
+	data, err := json.Marshal(notice)
+	if err != nil {
+		return
+	}
+	name := strconv.FormatInt(notice.When.UnixNano(), 10) + "-" + strconv.Itoa(notice.Node) + ".json"
+	tmp := filepath.Join(dir, "."+name)
+	if err := os.WriteFile(tmp, data, 0644); err == nil {
+		os.Rename(tmp, filepath.Join(dir, name))
+	}
+}
+
+// takeNotices picks up the notices sent to this node, oldest first.
+func (g *Game) takeNotices() []Notice {
+	return g.pickNotices(func(Notice) bool { return true })
+}
+
+// takeRaceNotices picks up only the notices arranging races. Plain ones
+// stay in the spool for the ticker, once the player is out of the lobby.
+func (g *Game) takeRaceNotices() []Notice {
+	return g.pickNotices(func(notice Notice) bool { return notice.Kind != "" })
+}
+
+// pickNotices takes the notices sent to this node that want is true of,
+// oldest first.
+func (g *Game) pickNotices(want func(Notice) bool) []Notice {
+	files, _ := filepath.Glob(filepath.Join(nodeSpool(g.User.NodeNum), "*.json"))
+	sort.Strings(files)
+	var notices []Notice
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		var notice Notice
+		if err != nil || json.Unmarshal(data, &notice) != nil {
+			os.Remove(file)
+			continue
+		}
+		if !want(notice) {
+			continue
+		}
+		os.Remove(file)
+		notices = append(notices, notice)
+	}
+	return notices
+}
+
+// announceRun tells the other nodes how the player's round ended.
+func (g *Game) announceRun(run *Run) {
+	if text, ok := endingNotices[run.Ending]; ok {
+		g.broadcast(text)
+	}
+}
+
+// pollNotices picks up the notices sent to this node and shows the latest
+// on the ticker line, unless the player would rather not see them or the
+// ticker is following a race. It also clears the ticker once a notice has
+// been up long enough. Race invites that turn up here are too late.
+func (g *Game) pollNotices() {
+	var latest *Notice
+	notices := g.takeNotices()
+	for i, notice := range notices {
+		if notice.Kind == "" {
+			latest = &notices[i]
+		}
+	}
+	g.turnAway(notices)
+
+	now := clock.Now()
+	switch {
+	case g.race != nil:
+	case latest != nil && !g.profile.Quiet:
+		g.layout.Ticker.Print(BgBlack + CyanHi + fmt.Sprintf("%s on node %d %s", latest.Alias, latest.Node, latest.Text) + Reset)
+		g.noticeUntil = now.Add(noticeShown)
+	case !g.noticeUntil.IsZero() && now.After(g.noticeUntil):
+		g.layout.Ticker.Clear()
+		g.noticeUntil = time.Time{}
+	}
+}
+
+// toggleNotices turns the ticker off or back on for this player.
+func (g *Game) toggleNotices() {
+	g.profile.Quiet = !g.profile.Quiet
+	g.saveProfile()
+	state := "on"
+	if g.profile.Quiet {
+		state = "off"
+		g.layout.Ticker.Clear()
+	}
+	g.message(BgBlue + CyanHi + "Notices from other nodes are " + state + "." + Reset)
+}
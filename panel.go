@@ -0,0 +1,74 @@
+package main
+
+// maxMessageLines is how tall a response may get, as in the original,
+// which had room for overflow2, overflow, message and redmessage.
+const maxMessageLines = 4
+
+// MessagePanel shows responses below the scene. Text is word-wrapped to the
+// panel's width and may end with a red "moral" line. When a response needs
+// more rows than the panel has, the scene is pushed up to make room and
+// drops back once a shorter message replaces it.
+type MessagePanel struct {
+	Region
+	Scene  Region
+	MaxH   int
+	grown  int  // rows currently taken from the scene
+	bgAttr Attr // fill for rows taken from the scene
+}
+
+func newMessagePanel(l Layout) *MessagePanel {
+	p := &MessagePanel{Region: l.Message, Scene: l.Scene, MaxH: l.Message.H, bgAttr: Attr{Bg: 4}}
+	if l.Scene.H > 0 {
+		p.MaxH = maxMessageLines
+	}
+	return p
+}
+
+// Show replaces the panel's text. moral, if not empty, goes underneath
+// in red.
+func (p *MessagePanel) Show(text, moral string) {
+	if p.W <= 0 {
+		return
+	}
+	lines := wrapText(text, p.W)
+	if text == "" {
+		lines = nil
+	}
+	if moral != "" {
+		for _, line := range wrapText(moral, p.W) {
+			lines = append(lines, BgBlue+RedHi+line+Reset)
+		}
+	}
+	if len(lines) > p.MaxH {
+		lines = lines[:p.MaxH]
+	}
+
+	p.resize(len(lines) - p.H)
+	p.Region.Clear()
+	top := p.Y - p.grown
+	if p.grown > 0 {
+		screen.Fill(p.Scene.X, top, p.Scene.W, p.grown, p.bgAttr)
+	}
+
+	panel := Region{X: p.X, Y: top, W: p.W, H: p.H + p.grown, FromBottom: true}
+	panel.draw(lines)
+}
+
+// Clear empties the panel and gives any borrowed rows back to the scene.
+func (p *MessagePanel) Clear() {
+	p.resize(0)
+	p.Region.Clear()
+}
+
+// resize takes extra rows from the bottom of the scene, redrawing the scene
+// shifted up, or gives them back.
+func (p *MessagePanel) resize(extra int) {
+	if extra < 0 || p.Scene.H == 0 {
+		extra = 0
+	}
+	if extra == p.grown {
+		return
+	}
+	p.grown = extra
+	screen.RestoreShifted(p.Scene.X, p.Scene.Y, p.Scene.W, p.Scene.H, extra)
+}
@@ -0,0 +1,54 @@
+package main
+
+import "fmt"
+
+// What to do with text the caller pastes instead of typing
+const (
+	pasteAccept = iota // treat it as typing
+	pasteReject        // throw it away and say so
+	pasteFlag          // keep it, but the run can't score or win speed awards
+)
+
+// parsePastePolicy reads the -paste flag.
+func parsePastePolicy(s string) (int, error) {
+	switch s {
+	case "accept":
+		return pasteAccept, nil
+	case "reject":
+		return pasteReject, nil
+	case "flag":
+		return pasteFlag, nil
+	}
+	return 0, fmt.Errorf("unknown paste policy %q (want accept, reject or flag)", s)
+}
+
+// pasteKey applies the paste policy to a key. Terminals in bracketed paste
+// mode wrap pasted text in start and end markers; pasteKey tracks them and
+// reports whether the key should be used.
+func (g *Game) pasteKey(key Key) bool {
+	switch key.Code {
+	case KeyPasteStart:
+		g.pasting = true
+		if g.pastePolicy == pasteReject {
+			g.message(BgBlue + RedHi + "No pasting! Type it yourself." + Reset)
+		}
+		return false
+	case KeyPasteEnd:
+		g.pasting = false
+		return false
+	}
+	if !g.pasting {
+		return true
+	}
+
+	switch g.pastePolicy {
+	case pasteReject:
+		return false
+	case pasteFlag:
+		if g.GameState.AppState == statePlaying && !g.GameState.Pasted {
+			g.GameState.Pasted = true
+			g.message(BgBlue + RedHi + "Pasted! This run won't count for scores or speed awards." + Reset)
+		}
+	}
+	return true
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"io"
+)
+
+// PETSCII control codes understood by C64 terminal programs
+const (
+	petClear     = 147
+	petHome      = 19
+	petDown      = 17
+	petUp        = 145
+	petRight     = 29
+	petLeft      = 157
+	petRvsOn     = 18
+	petRvsOff    = 146
+	petLowercase = 14 // switch to the upper/lowercase character set
+	petLockCase  = 8  // stop Shift+C= from switching it back
+	petDelete    = 20
+	petReturn    = 13
+)
+
+// ANSI color numbers to C64 color codes, normal and bright
+var (
+	petColors = [8]byte{
+		144, // black
+		28,  // red
+		30,  // green
+		149, // brown
+		31,  // blue
+		156, // purple
+		159, // cyan
+		155, // light grey
+	}
+	petColorsBright = [8]byte{
+		151, // dark grey
+		150, // light red
+		153, // light green
+		158, // yellow
+		154, // light blue
+		156, // purple
+		159, // cyan
+		5,   // white
+	}
+)
+
+// petsciiRenderer draws on a 40x25 Commodore 64 screen. Text arrives with
+// ANSI colors and cursor moves, like everywhere else in the door, and is
+// translated to PETSCII control codes. The C64 has one background color,
+// so colored backgrounds are drawn with reverse-video spaces.
+type petsciiRenderer struct {
+	w      *frameWriter
+	st     ansiState
+	cx, cy int  // where the C64's cursor really is
+	color  byte // last color code sent
+	rvs    bool
+}
+
+func newPETSCIIRenderer(w io.Writer) *petsciiRenderer {
+	r := &petsciiRenderer{w: newFrameWriter(w), st: newAnsiState(40, 25)}
+	r.w.Write([]byte{petLowercase, petLockCase})
+	return r
+}
+
+func (r *petsciiRenderer) Width() int  { return 40 }
+func (r *petsciiRenderer) Height() int { return 25 }
+
+func (r *petsciiRenderer) Clear() {
+	r.clear()
+	r.st.X, r.st.Y = 1, 1
+}
+
+func (r *petsciiRenderer) MoveTo(x, y int) {
+	r.st.MoveTo(x, y)
+	r.moveCursor(r.st.X, r.st.Y)
+}
+
+func (r *petsciiRenderer) SetAttr(a Attr) {
+	r.st.Attr = a
+}
+
+func (r *petsciiRenderer) Write(s string) {
+	r.st.feed(s, r)
+	r.moveCursor(clamp(r.st.X, 1, 40), clamp(r.st.Y, 1, 25))
+}
+
+// ShowCursor is a no-op: C64 terminal programs always show their cursor.
+func (r *petsciiRenderer) ShowCursor(show bool) {}
+
+// MouseReporting is a no-op: there's no mouse protocol for PETSCII.
+func (r *petsciiRenderer) MouseReporting(on bool) {}
+
+// BracketedPaste is a no-op: C64 terminal programs don't paste.
+func (r *petsciiRenderer) BracketedPaste(on bool) {}
+
+func (r *petsciiRenderer) Flush() error {
+	return r.w.Flush()
+}
+
+func (r *petsciiRenderer) clear() {
+	r.w.Write([]byte{petClear})
+	r.cx, r.cy = 1, 1
+}
+
+func (r *petsciiRenderer) putCell(x, y int, c rune, a Attr) {
+	if x == 40 && y == 25 {
+		return // printing the last cell would scroll the screen
+	}
+	code, rvs := petsciiGlyph(c)
+	color := petColor(a.Fg, a.Bold)
+	if c == ' ' && a.Bg != 0 {
+		code, rvs = ' ', true
+		color = petColor(a.Bg, false)
+	}
+
+	r.moveCursor(x, y)
+	var out []byte
+	if code != ' ' || rvs {
+		if color != r.color {
+			out = append(out, color)
+			r.color = color
+		}
+	}
+	if rvs != r.rvs {
+		if rvs {
+			out = append(out, petRvsOn)
+		} else {
+			out = append(out, petRvsOff)
+		}
+		r.rvs = rvs
+	}
+	r.w.Write(append(out, code))
+
+	r.cx++
+	if r.cx > 40 {
+		r.cx = 1
+		r.cy++
+	}
+}
+
+// moveCursor sends the shortest run of cursor keys that gets from where the
+// cursor is to (x,y).
+func (r *petsciiRenderer) moveCursor(x, y int) {
+	if x == r.cx && y == r.cy {
+		return
+	}
+	var out []byte
+	relative := abs(x-r.cx) + abs(y-r.cy)
+	if 1+(x-1)+(y-1) < relative {
+		out = append(out, petHome)
+		r.cx, r.cy = 1, 1
+	}
+	for ; r.cy < y; r.cy++ {
+		out = append(out, petDown)
+	}
+	for ; r.cy > y; r.cy-- {
+		out = append(out, petUp)
+	}
+	for ; r.cx < x; r.cx++ {
+		out = append(out, petRight)
+	}
+	for ; r.cx > x; r.cx-- {
+		out = append(out, petLeft)
+	}
+	r.w.Write(out)
+}
+
+func petColor(c int, bright bool) byte {
+	if bright {
+		return petColorsBright[c&7]
+	}
+	return petColors[c&7]
+}
+
+// petsciiGlyph maps a character to the upper/lowercase PETSCII set. Block
+// characters from the ANSI art become the nearest C64 graphic, some of
+// which need reverse video.
+func petsciiGlyph(c rune) (byte, bool) {
+	switch {
+	case c >= 'a' && c <= 'z':
+		return byte(c-'a') + 0x41, false
+	case c >= 'A' && c <= 'Z':
+		return byte(c-'A') + 0xc1, false
+	case c >= ' ' && c <= '@', c == '[', c == ']':
+		return byte(c), false
+	}
+
+	switch c {
+	case '█':
+		return ' ', true
+	case '▄':
+		return 0xa2, false
+	case '▀':
+		return 0xa2, true
+	case '▌':
+		return 0xa1, false
+	case '▐':
+		return 0xa1, true
+	case '░', '▒', '▓':
+		return 0xa6, false
+	case '─', '═':
+		return 0xc0, false
+	case '│', '║', '|':
+		return 0xdd, false
+	case '\\':
+		return '/', false
+	case '_':
+		return 0xa4, false
+	case '`', '´':
+		return '\'', false
+	case '{':
+		return '(', false
+	case '}':
+		return ')', false
+	case '~':
+		return '-', false
+	case '»':
+		return '>', false
+	case '«':
+		return '<', false
+	case '^':
+		return 0x5e, false
+	}
+	return '?', false
+}
+
+// petsciiRune turns a character typed on a C64 into the character an ANSI
+// caller would have sent for the same key.
+func petsciiRune(b byte) rune {
+	switch {
+	case b == petDelete:
+		return '\b'
+	case b >= 0x41 && b <= 0x5a:
+		return rune(b + 0x20)
+	case b >= 0xc1 && b <= 0xda:
+		return rune(b - 0x80)
+	case b >= 0x61 && b <= 0x7a:
+		return rune(b - 0x20)
+	case b >= 0x80:
+		return 0 // graphics and control codes; dropped
+	}
+	return rune(b)
+}
+
+// petsciiKey reports the cursor key a C64 control code stands for.
+func petsciiKey(b byte) (Key, bool) {
+	switch b {
+	case petUp:
+		return Key{Code: KeyUp}, true
+	case petDown:
+		return Key{Code: KeyDown}, true
+	case petLeft:
+		return Key{Code: KeyLeft}, true
+	case petRight:
+		return Key{Code: KeyRight}, true
+	case petHome:
+		return Key{Code: KeyHome}, true
+	}
+	return Key{}, false
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/hex"
+	"path/filepath"
+)
+
+// playersDir holds a profile for each player, under dataDir.
+const playersDir = "players"
+
+// Profile is what the door remembers about a player between calls.
+type Profile struct {
+	Alias string
+	Quiet bool // don't show notices from other nodes
+
+	LastDaily   string // date of the last daily challenge played
+	DailyStreak int    // days in a row the daily challenge was played
+	BestStreak  int
+}
+
+// profilePath returns where alias's profile is kept. The name spells out
+// the alias exactly, so "Bob" and "bob" don't share one.
+func profilePath(alias string) string {
+	return dataPath(filepath.Join(playersDir, hex.EncodeToString([]byte(alias))+".json"))
+}
+
+// loadProfile reads alias's profile, or starts a new one.
+func loadProfile(alias string) Profile {
+	p := Profile{Alias: alias}
+	if err := loadJSON(profilePath(alias), &p); err != nil {
+		inputLog(LogLevelError, "SysOp", "Failed to read the profile of "+alias+": "+err.Error())
+	}
+	return p
+}
+
+// saveProfile writes the player's profile back.
+func (g *Game) saveProfile() {
+	if err := saveJSON(profilePath(g.profile.Alias), g.profile); err != nil {
+		inputLog(LogLevelError, "SysOp", "Failed to save the profile of "+g.profile.Alias+": "+err.Error())
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import "testing"
+
+func TestProfilesKeptApart(t *testing.T) {
+	aliases := []string{"Bob", "bob", "a.b", "a_b", "a b", "Zoë"}
+	useDataDir(t)
+	for i, alias := range aliases {
+		g := &Game{profile: Profile{Alias: alias, DailyStreak: i + 1}}
+		g.saveProfile()
+	}
+	for i, alias := range aliases {
+		if got := loadProfile(alias); got.Alias != alias || got.DailyStreak != i+1 {
+			t.Errorf("%q got the profile of %q, streak %d", alias, got.Alias, got.DailyStreak)
+		}
+	}
+}
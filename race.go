@@ -0,0 +1,444 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// racesDir holds a directory for each race, under dataDir: a state file
+// for each racer and, while somebody's sitting on it, the toilet.
+const racesDir = "races"
+
+// Kinds of notice that arrange a race
+const (
+	noticeRaceInvite  = "race-invite"  // the sender will race you
+	noticeRaceAccept  = "race-accept"  // the sender will race you, if you still will
+	noticeRaceConfirm = "race-confirm" // you're on
+	noticeRaceBusy    = "race-busy"    // taken already
+)
+
+const (
+	racePoll   = 250 * time.Millisecond // how often the lobby checks its spool
+	raceAnswer = 5 * time.Second        // how long an invite waits for an answer
+	raceWait   = 3 * time.Minute        // how long the lobby waits for an opponent
+	raceGrace  = 10 * time.Second       // wait for the opponent past their timer
+	raceKept   = time.Hour              // how long finished races are kept
+	toiletLost = 3 * noticePoll         // no heartbeat for this long and the toilet is free
+)
+
+// Race is the race the player is in. Both racers have their own timer, but
+// there's only one toilet.
+type Race struct {
+	ID            string
+	OpponentAlias string
+	OpponentNode  int
+	seated        bool // this racer holds the toilet
+}
+
+// RacerState is what a racer tells the other, every second and once more
+// when they finish.
+type RacerState struct {
+	Alias    string
+	Node     int
+	Left     time.Duration
+	Door     bool
+	Pants    bool
+	Standing bool
+	Finished bool
+	Ending   Ending
+	Score    int
+	Elapsed  time.Duration
+}
+
+func raceDir(id string) string {
+	return dataPath(filepath.Join(racesDir, id))
+}
+
+func racerPath(id string, node int) string {
+	return filepath.Join(raceDir(id), strconv.Itoa(node)+".json")
+}
+
+// findRace puts the player in the lobby. If someone is already waiting
+// there the two race at once; otherwise the player waits for the next one
+// to come along. Either way the race is only on once the inviter has
+// confirmed the accept, so both end up in the same one.
+func (g *Game) findRace(inputChan chan Key, errorChan chan error, doneChan chan bool) {
+	g.GameState.AppState = stateLobby
+	g.updateGameEnvironment(inputChan)
+	CursorHide()
+	defer CursorShow()
+	sweepRaces()
+
+	CenterText("RACE LOBBY", g.User.W)
+	Println("")
+	Println("Two players, two timers, one toilet.")
+	Println("")
+
+	// Challenge whoever is waiting, one at a time, until somebody's free
+	for _, node := range onlineNodes() {
+		if !node.Lobby || node.Node == g.User.NodeNum {
+			continue
+		}
+		id := strconv.Itoa(g.User.NodeNum) + "-" + strconv.FormatInt(clock.Now().UnixNano(), 10)
+		invite := g.newNotice(noticeRaceInvite, "")
+		invite.Race, invite.Level = id, g.level
+		sendNotice(node.Node, invite)
+		Println("Challenging " + node.Alias + " on node " + strconv.Itoa(node.Node) + "...")
+		Flush()
+		if race, level := g.awaitAnswer(id, node.Node); race != nil {
+			g.runRace(race, level, inputChan, errorChan, doneChan)
+			return
+		}
+	}
+
+	Println("Waiting for an opponent. Press a key to give up.")
+	Flush()
+	poll := clock.NewTicker(racePoll)
+	defer poll.Stop()
+	giveUp := clock.Now().Add(raceWait)
+	for clock.Now().Before(giveUp) {
+		select {
+		case <-inputChan:
+			g.leaveLobby(inputChan, "You left the lobby.")
+			return
+		case <-poll.Ticks():
+			notices := g.takeRaceNotices()
+			for i, notice := range notices {
+				if notice.Kind != noticeRaceInvite {
+					continue
+				}
+				Println(notice.Alias + " on node " + strconv.Itoa(notice.Node) + " wants to race!")
+				Flush()
+				g.turnAway(notices[i+1:])
+				if race := g.acceptInvite(notice); race != nil {
+					g.runRace(race, notice.Level, inputChan, errorChan, doneChan)
+					return
+				}
+				Println("They went off without you. Still waiting...")
+				Flush()
+				break
+			}
+		}
+	}
+	g.leaveLobby(inputChan, "Nobody came. Try again later.")
+}
+
+// awaitAnswer waits for the answer to an invite, confirming it if it was
+// accepted. If the node invited the player at the same time, the lower
+// node's invite stands: the player accepts theirs, on their level.
+func (g *Game) awaitAnswer(id string, node int) (*Race, int) {
+	poll := clock.NewTicker(racePoll)
+	defer poll.Stop()
+	deadline := clock.Now().Add(raceAnswer)
+	for clock.Now().Before(deadline) {
+		<-poll.Ticks()
+		notices := g.takeRaceNotices()
+		for i, notice := range notices {
+			switch {
+			case notice.Kind == noticeRaceInvite && notice.Node == node && node < g.User.NodeNum:
+				g.turnAway(notices[i+1:])
+				return g.acceptInvite(notice), notice.Level
+			case notice.Kind == noticeRaceInvite:
+				g.turnAway([]Notice{notice})
+			case notice.Race == id && notice.Node == node && notice.Kind == noticeRaceAccept:
+				confirm := g.newNotice(noticeRaceConfirm, "")
+				confirm.Race = id
+				sendNotice(node, confirm)
+				g.turnAway(notices[i+1:])
+				return &Race{ID: id, OpponentAlias: notice.Alias, OpponentNode: node}, g.level
+			case notice.Race == id && notice.Node == node:
+				g.turnAway(notices[i+1:])
+				return nil, 0
+			}
+		}
+	}
+	return nil, 0
+}
+
+// acceptInvite answers an invite and waits for the inviter to confirm the
+// race is on. Nothing's settled until they do: they may have given up, or
+// found someone else.
+func (g *Game) acceptInvite(invite Notice) *Race {
+	accept := g.newNotice(noticeRaceAccept, "")
+	accept.Race = invite.Race
+	sendNotice(invite.Node, accept)
+
+	poll := clock.NewTicker(racePoll)
+	defer poll.Stop()
+	deadline := clock.Now().Add(raceAnswer)
+	for clock.Now().Before(deadline) {
+		<-poll.Ticks()
+		notices := g.takeRaceNotices()
+		for i, notice := range notices {
+			if notice.Kind == noticeRaceConfirm && notice.Race == invite.Race && notice.Node == invite.Node {
+				g.turnAway(notices[i+1:])
+				return &Race{ID: invite.Race, OpponentAlias: invite.Alias, OpponentNode: invite.Node}
+			}
+			g.turnAway([]Notice{notice})
+		}
+	}
+	return nil
+}
+
+// turnAway tells the senders of any invites that the player is taken.
+// Anything else is too late to matter.
+func (g *Game) turnAway(notices []Notice) {
+	for _, notice := range notices {
+		if notice.Kind != noticeRaceInvite {
+			continue
+		}
+		busy := g.newNotice(noticeRaceBusy, "")
+		busy.Race = notice.Race
+		sendNotice(notice.Node, busy)
+	}
+}
+
+// leaveLobby goes back to the main menu with a word about why.
+func (g *Game) leaveLobby(inputChan chan Key, text string) {
+	g.GameState.AppState = stateMainMenu
+	g.updateGameEnvironment(inputChan)
+	g.message(BgBlue + CyanHi + text + Reset)
+}
+
+// runRace plays a round as a race on the inviter's level, then shows how
+// it went.
+func (g *Game) runRace(race *Race, level int, inputChan chan Key, errorChan chan error, doneChan chan bool) {
+	defer func(picked int) { g.level = picked }(g.level)
+	g.level = level
+	if err := os.MkdirAll(raceDir(race.ID), 0755); err != nil {
+		inputLog(LogLevelError, "SysOp", "Failed to set up the race: "+err.Error())
+		g.leaveLobby(inputChan, "The race couldn't be set up. Tell the sysop.")
+		return
+	}
+	Println("Racing " + race.OpponentAlias + " on " + g.difficulty().Name + "!")
+	Flush()
+	clock.Sleep(2 * time.Second)
+
+	g.race = race
+	g.GameState.AppState = statePlaying
+	g.startGame(inputChan, errorChan, doneChan)
+	g.leaveToilet()
+	g.showRaceResult(inputChan)
+	g.race = nil
+}
+
+// takeToilet sits the player on the toilet, if nobody else is on it. Out of
+// a race it's always free. The toilet file says whose node holds it, so a
+// racer who drops while sitting doesn't keep it for good.
+func (g *Game) takeToilet() bool {
+	if g.race == nil {
+		return true
+	}
+	path := filepath.Join(raceDir(g.race.ID), "toilet")
+	if !g.claimToilet(path) {
+		if !g.toiletAbandoned(path) {
+			return false
+		}
+		os.Remove(path)
+		if !g.claimToilet(path) {
+			return false
+		}
+	}
+	g.race.seated = true
+	return true
+}
+
+// claimToilet creates the toilet file with the player's node in it, if
+// there isn't one.
+func (g *Game) claimToilet(path string) bool {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return false
+	}
+	f.WriteString(strconv.Itoa(g.User.NodeNum))
+	f.Close()
+	return true
+}
+
+// toiletAbandoned reports whether the racer on the toilet has dropped
+// without getting up: their node has stopped its heartbeat, or someone
+// else is on it now.
+func (g *Game) toiletAbandoned(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return os.IsNotExist(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	node, err := strconv.Atoi(string(data))
+	if err != nil {
+		// Cut off before it could say whose it was
+		return clock.Now().Sub(info.ModTime()) > toiletLost
+	}
+	var status NodeStatus
+	if err := loadJSON(nodeStatusPath(node), &status); err != nil {
+		inputLog(LogLevelError, "SysOp", "Failed to read the node status: "+err.Error())
+		return false
+	}
+	return status.Alias != g.race.OpponentAlias || clock.Now().Sub(status.Heartbeat) > toiletLost
+}
+
+// leaveToilet frees the toilet for the other racer.
+func (g *Game) leaveToilet() {
+	if g.race == nil || !g.race.seated {
+		return
+	}
+	os.Remove(filepath.Join(raceDir(g.race.ID), "toilet"))
+	g.race.seated = false
+}
+
+// racerState is what the player tells the other racer.
+func (g *Game) racerState() RacerState {
+	s := RacerState{
+		Alias:    g.User.Alias,
+		Node:     g.User.NodeNum,
+		Left:     g.GameState.RemainingTime,
+		Door:     g.GameState.Door,
+		Pants:    g.GameState.Pants,
+		Standing: g.GameState.Standing,
+	}
+	if g.lastRun != nil {
+		s.Finished = true
+		s.Ending = g.lastRun.Ending
+		s.Score = g.lastRun.Score
+		s.Elapsed = g.lastRun.Elapsed
+	}
+	return s
+}
+
+// postRaceState tells the other racer how the player is doing.
+func (g *Game) postRaceState() {
+	if g.race == nil {
+		return
+	}
+	if err := saveJSON(racerPath(g.race.ID, g.User.NodeNum), g.racerState()); err != nil {
+		inputLog(LogLevelError, "SysOp", "Failed to write the race state: "+err.Error())
+	}
+}
+
+// updateRace tells the other racer how the player is doing and shows how
+// they are doing on the ticker line.
+func (g *Game) updateRace() {
+	if g.race == nil {
+		return
+	}
+	g.postRaceState()
+	var them RacerState
+	if err := loadJSON(racerPath(g.race.ID, g.race.OpponentNode), &them); err != nil || them.Alias == "" {
+		g.layout.Ticker.Print(BgBlack + CyanHi + "Racing " + g.race.OpponentAlias + "..." + Reset)
+		return
+	}
+	g.layout.Ticker.Print(BgBlack + CyanHi + "vs " + them.Alias + ": " + WhiteHi + describeRacer(them) + Reset)
+}
+
+// loadRacer reads how the other racer is doing into s. It stays as it was
+// if they haven't said yet.
+func (g *Game) loadRacer(s *RacerState) {
+	if err := loadJSON(racerPath(g.race.ID, g.race.OpponentNode), s); err != nil {
+		inputLog(LogLevelError, "SysOp", "Failed to read the race state: "+err.Error())
+	}
+}
+
+// describeRacer says where a racer has got to.
+func describeRacer(s RacerState) string {
+	if s.Finished {
+		return "finished, " + string(s.Ending)
+	}
+	where := "standing"
+	if !s.Standing {
+		where = "on the toilet"
+	} else if s.Door {
+		where = "standing, door open"
+	}
+	pants := "pants on"
+	if !s.Pants {
+		pants = "pants off"
+	}
+	return fmt.Sprintf("%s, %s, %s left", where, pants, formatTimer(s.Left))
+}
+
+// beats reports whether racer a beat racer b: a win beats a loss, the
+// faster of two wins takes it, and between two losses the score decides.
+func beats(a, b RacerState) bool {
+	switch {
+	case !b.Finished:
+		return a.Finished
+	case a.Ending.Won() != b.Ending.Won():
+		return a.Ending.Won()
+	case a.Ending.Won():
+		return a.Elapsed < b.Elapsed
+	}
+	return a.Score > b.Score
+}
+
+// showRaceResult waits for the other racer to finish, then shows who won.
+func (g *Game) showRaceResult(inputChan chan Key) {
+	g.GameState.AppState = stateRaceResult
+	g.updateGameEnvironment(inputChan)
+	CursorHide()
+
+	me := g.racerState()
+	g.postRaceState()
+
+	var them RacerState
+	g.loadRacer(&them)
+	if !them.Finished {
+		Println("Waiting for " + g.race.OpponentAlias + " to finish. Press a key to stop waiting.")
+		Flush()
+		poll := clock.NewTicker(time.Second)
+		deadline := clock.Now().Add(them.Left + raceGrace)
+	wait:
+		for !them.Finished && clock.Now().Before(deadline) {
+			select {
+			case <-inputChan:
+				break wait
+			case <-poll.Ticks():
+				g.loadRacer(&them)
+			}
+		}
+		poll.Stop()
+		ClearScreen()
+	}
+	if them.Alias == "" {
+		them.Alias = g.race.OpponentAlias
+	}
+
+	CenterText("RACE RESULT", g.User.W)
+	Println("")
+	row := "%-14s%-14s%6s%7s\n"
+	Printf(Cyan+row+Reset, "Racer", "Ending", "Time", "Score")
+	for _, s := range []RacerState{me, them} {
+		if s.Finished {
+			Printf(WhiteHi+row+Reset, clip(s.Alias, 13), string(s.Ending), formatTimer(s.Elapsed), strconv.Itoa(s.Score))
+		} else {
+			Printf(White+row+Reset, clip(s.Alias, 13), "never finished", "", "")
+		}
+	}
+	Println("")
+	switch {
+	case beats(me, them):
+		Println(YellowHi + "You beat " + them.Alias + "!" + Reset)
+		postNews(g.User.Alias + " beat " + them.Alias + " in a race.")
+	case beats(them, me):
+		Println(RedHi + them.Alias + " beat you." + Reset)
+	default:
+		Println(CyanHi + "It's a draw." + Reset)
+	}
+
+	g.readSingleKeyPress(inputChan, stateMainMenu)
+}
+
+// sweepRaces clears away races nobody has touched for a while.
+func sweepRaces() {
+	dirs, _ := filepath.Glob(dataPath(filepath.Join(racesDir, "*")))
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err == nil && clock.Now().Sub(info.ModTime()) > raceKept {
+			os.RemoveAll(dir)
+		}
+	}
+}
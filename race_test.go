@@ -0,0 +1,141 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// racers returns n games in the same race, one a node, each with a fresh
+// heartbeat.
+func racers(t *testing.T, n int) []*Game {
+	t.Helper()
+	useDataDir(t)
+	var games []*Game
+	for node := 1; node <= n; node++ {
+		g := &Game{User: User{Alias: "Racer", NodeNum: node}, race: &Race{ID: "r1", OpponentAlias: "Racer"}}
+		g.GameState.AppState = statePlaying
+		g.updateStatus()
+		games = append(games, g)
+	}
+	if err := os.MkdirAll(raceDir("r1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return games
+}
+
+func TestTakeToiletOnce(t *testing.T) {
+	games := racers(t, 8)
+	var wg sync.WaitGroup
+	taken := make([]bool, len(games))
+	for i, g := range games {
+		wg.Add(1)
+		go func(i int, g *Game) {
+			defer wg.Done()
+			taken[i] = g.takeToilet()
+		}(i, g)
+	}
+	wg.Wait()
+
+	holders := 0
+	for i, ok := range taken {
+		if ok {
+			holders++
+			if !games[i].race.seated {
+				t.Errorf("node %d took the toilet but isn't seated", i+1)
+			}
+		}
+	}
+	if holders != 1 {
+		t.Errorf("%d racers on the toilet, want 1", holders)
+	}
+}
+
+func TestLeaveToilet(t *testing.T) {
+	tests := []struct {
+		name     string
+		leaver   int // index of the racer who gets up
+		wantFree bool
+	}{
+		{"holder gets up", 0, true},
+		{"the other can't free it", 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			games := racers(t, 2)
+			if !games[0].takeToilet() {
+				t.Fatal("toilet taken before anyone sat")
+			}
+			games[tt.leaver].leaveToilet()
+			if got := games[1].takeToilet(); got != tt.wantFree {
+				t.Errorf("second racer got the toilet %v, want %v", got, tt.wantFree)
+			}
+		})
+	}
+}
+
+func TestToiletOutOfRace(t *testing.T) {
+	g := newTestGame(t)
+	if !g.takeToilet() || !g.takeToilet() {
+		t.Errorf("toilet taken with no race on")
+	}
+}
+
+func TestToiletAbandoned(t *testing.T) {
+	tests := []struct {
+		name     string
+		holder   func(t *testing.T, path string) // what's left of the racer on it
+		wantFree bool
+	}{
+		{"still there", func(t *testing.T, path string) {}, false},
+		{"heartbeat stopped", func(t *testing.T, path string) {
+			saveJSON(nodeStatusPath(1), NodeStatus{Node: 1, Alias: "Racer", Heartbeat: clock.Now().Add(-time.Minute)})
+		}, true},
+		{"node gone", func(t *testing.T, path string) {
+			os.Remove(nodeStatusPath(1))
+		}, true},
+		{"someone else on the node", func(t *testing.T, path string) {
+			saveJSON(nodeStatusPath(1), NodeStatus{Node: 1, Alias: "Other", Heartbeat: clock.Now()})
+		}, true},
+		{"cut off while sitting", func(t *testing.T, path string) {
+			os.WriteFile(path, nil, 0644)
+			old := time.Now().Add(-time.Minute)
+			os.Chtimes(path, old, old)
+		}, true},
+		{"just sitting down", func(t *testing.T, path string) {
+			os.WriteFile(path, nil, 0644)
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			games := racers(t, 2)
+			if !games[0].takeToilet() {
+				t.Fatal("toilet taken before anyone sat")
+			}
+			tt.holder(t, filepath.Join(raceDir("r1"), "toilet"))
+			if got := games[1].takeToilet(); got != tt.wantFree {
+				t.Errorf("second racer got the toilet %v, want %v", got, tt.wantFree)
+			}
+		})
+	}
+}
+
+func TestLobbyLeavesPlainNotices(t *testing.T) {
+	games := racers(t, 2)
+	me, them := games[0], games[1]
+	sendNotice(1, them.newNotice("", "just shit their pants!"))
+	invite := them.newNotice(noticeRaceInvite, "")
+	invite.When = invite.When.Add(time.Millisecond)
+	sendNotice(1, invite)
+
+	race := me.takeRaceNotices()
+	if len(race) != 1 || race[0].Kind != noticeRaceInvite {
+		t.Errorf("lobby took %v, want just the invite", race)
+	}
+	plain := me.takeNotices()
+	if len(plain) != 1 || plain[0].Text != "just shit their pants!" {
+		t.Errorf("left %v, want the plain notice", plain)
+	}
+}
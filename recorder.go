@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// recordingsDir holds each node's session recordings, under dataDir.
+const recordingsDir = "recordings"
+
+// Recording settings, from the command line. Recordings hold everything
+// the caller types, so the sysop has to ask for them.
+var (
+	recordSessions = false
+	recordingDays  = 14 // how long recordings are kept
+)
+
+// recording is the session being recorded, if it is.
+var recording *recorder
+
+// recorder writes a session to an asciicast v2 file: a header line, then
+// one line for each burst of output or input, stamped with the seconds
+// since the start. asciicast wants UTF-8, so CP437 is converted.
+type recorder struct {
+	mutex   sync.Mutex
+	f       *os.File
+	start   time.Time
+	cp437   bool
+	partial map[string][]byte // a UTF-8 character cut short by the last write, by kind
+}
+
+// asciicastHeader is the first line of an asciicast v2 file.
+type asciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// startRecording opens a recording of u's session. PETSCII sessions aren't
+// recorded: a terminal can't play them back.
+func startRecording(u User) (*recorder, error) {
+	if u.Emulation == EmulationPETSCII {
+		return nil, nil
+	}
+	now := clock.Now()
+	dir := dataPath(filepath.Join(recordingsDir, strconv.Itoa(u.NodeNum)))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	name := now.Format("20060102-150405") + "-" + fileName(u.Alias) + ".cast"
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	header, _ := json.Marshal(asciicastHeader{
+		Version:   2,
+		Width:     u.W,
+		Height:    u.H,
+		Timestamp: now.Unix(),
+		Title:     fmt.Sprintf("%s on node %d", u.Alias, u.NodeNum),
+		Env:       map[string]string{"TERM": "ansi"},
+	})
+	f.Write(append(header, '\n'))
+	return &recorder{f: f, start: now, cp437: u.Encoding() == EncodingCP437, partial: map[string][]byte{}}, nil
+}
+
+// Write records output on its way to the caller.
+func (r *recorder) Write(p []byte) (int, error) {
+	r.event("o", p)
+	return len(p), nil
+}
+
+// Input records what the caller typed.
+func (r *recorder) Input(p []byte) {
+	r.event("i", p)
+}
+
+// event writes one line of the recording. Each goes straight to the file,
+// so a dropped call still leaves a recording that plays. Writes can end
+// partway through a UTF-8 character; the rest of it comes with the next.
+func (r *recorder) event(kind string, p []byte) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var text string
+	if r.cp437 {
+		text, _ = charmap.CodePage437.NewDecoder().String(string(p))
+	} else {
+		data := append(r.partial[kind], p...)
+		n := wholeUTF8(data)
+		r.partial[kind] = append([]byte(nil), data[n:]...)
+		text = string(data[:n])
+	}
+	if text == "" {
+		return
+	}
+	line, err := json.Marshal([]interface{}{clock.Now().Sub(r.start).Seconds(), kind, text})
+	if err != nil {
+		return
+	}
+	r.f.Write(append(line, '\n'))
+}
+
+// wholeUTF8 returns how much of b is whole UTF-8 characters, leaving out
+// one cut short at the end.
+func wholeUTF8(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(b)
+}
+
+// Close finishes the recording.
+func (r *recorder) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.f.Close()
+}
+
+// sessionOutput is where output to the caller goes: the terminal, the
+// recording when there is one, and the sysop when they can watch. Only the
+// caller's copy is escaped for telnet.
+func sessionOutput() io.Writer {
+	out := []io.Writer{telnetOut}
+	if recording != nil {
+		out = append(out, recording)
+	}
+	if spectating != nil {
+		out = append(out, spectating)
+	}
+	return io.MultiWriter(out...)
+}
+
+// pruneRecordings deletes recordings more than recordingDays old.
+func pruneRecordings(now time.Time) {
+	files, err := filepath.Glob(dataPath(filepath.Join(recordingsDir, "*", "*.cast")))
+	if err != nil {
+		return
+	}
+	oldest := now.AddDate(0, 0, -recordingDays)
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil && info.ModTime().Before(oldest) {
+			os.Remove(file)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Terminal emulations, as found on line 10 of door32.sys. door32.sys has
+// no code for PETSCII, so we use the next free number (or -petscii).
+const (
+	EmulationASCII   = 0
+	EmulationANSI    = 1
+	EmulationPETSCII = 5
+)
+
+// Renderer turns the door's screen operations into bytes for the caller's
+// terminal. Coordinates are 1-based; 0 is treated as 1.
+type Renderer interface {
+	Width() int
+	Height() int
+	Clear()
+	MoveTo(x, y int)
+	SetAttr(a Attr)
+	// Write prints text that may carry ANSI color and cursor sequences.
+	Write(s string)
+	ShowCursor(show bool)
+	// MouseReporting asks the terminal to report clicks, or to stop.
+	MouseReporting(on bool)
+	// BracketedPaste asks the terminal to mark pasted text, or to stop.
+	BracketedPaste(on bool)
+	// Flush sends anything buffered to the caller.
+	Flush() error
+}
+
+// term draws for the current caller. It starts out as plain ANSI so early
+// errors still print, and is replaced once the drop file has been read.
+var term Renderer = newANSIRenderer(telnetOut, false, 80, 25)
+
+func newRenderer(u User) Renderer {
+	if u.Emulation == EmulationPETSCII {
+		return newPETSCIIRenderer(telnetOut)
+	}
+	return newANSIRenderer(sessionOutput(), u.Encoding() == EncodingCP437, u.W, u.H)
+}
+
+// Print, Printf and Println format like their fmt counterparts and draw
+// the result on the screen at the cursor.
+func Print(a ...interface{}) {
+	screen.Write(fmt.Sprint(a...))
+}
+
+func Printf(format string, a ...interface{}) {
+	screen.Write(fmt.Sprintf(format, a...))
+}
+
+func Println(a ...interface{}) {
+	screen.Write(fmt.Sprintln(a...))
+}
+
+// artPath returns the art file to show for name, preferring a 40-column
+// version (name40.ans) on narrow screens.
+func artPath(name string) string {
+	if term.Width() < 80 {
+		narrow := ArtFileDir + name + "40.ans"
+		if _, err := os.Stat(narrow); err == nil {
+			return narrow
+		}
+	}
+	return ArtFileDir + name + ".ans"
+}
+
+// frameWriter holds what is drawn until Flush and then sends it in one
+// write, so nothing else going to the caller, like a telnet answer, can
+// land in the middle of an escape sequence.
+type frameWriter struct {
+	bytes.Buffer
+	out io.Writer
+}
+
+func newFrameWriter(w io.Writer) *frameWriter {
+	return &frameWriter{out: w}
+}
+
+func (f *frameWriter) Flush() error {
+	if f.Len() == 0 {
+		return nil
+	}
+	_, err := f.out.Write(f.Bytes())
+	f.Reset()
+	return err
+}
+
+// ansiRenderer writes ANSI escape sequences, encoding text as CP437 for BBS
+// callers or UTF-8 for the local console.
+type ansiRenderer struct {
+	w      *frameWriter
+	cp437  bool
+	width  int
+	height int
+}
+
+func newANSIRenderer(w io.Writer, cp437 bool, width, height int) *ansiRenderer {
+	return &ansiRenderer{w: newFrameWriter(w), cp437: cp437, width: width, height: height}
+}
+
+func (r *ansiRenderer) Width() int  { return r.width }
+func (r *ansiRenderer) Height() int { return r.height }
+
+func (r *ansiRenderer) Clear() {
+	fmt.Fprintln(r.w, EraseScreen)
+	r.MoveTo(0, 0)
+}
+
+func (r *ansiRenderer) MoveTo(x, y int) {
+	fmt.Fprintf(r.w, Esc+"%d;%df", y, x)
+}
+
+func (r *ansiRenderer) SetAttr(a Attr) {
+	r.w.WriteString(sgrString(a))
+}
+
+func (r *ansiRenderer) Write(s string) {
+	if !r.cp437 {
+		r.w.WriteString(s)
+		return
+	}
+	for _, c := range s {
+		if c < 0x80 {
+			r.w.WriteByte(byte(c))
+		} else if b, ok := charmap.CodePage437.EncodeRune(c); ok {
+			r.w.WriteByte(b)
+		} else {
+			r.w.WriteByte('?')
+		}
+	}
+}
+
+func (r *ansiRenderer) ShowCursor(show bool) {
+	if show {
+		r.w.WriteString(Esc + "?25h")
+	} else {
+		r.w.WriteString(Esc + "?25l")
+	}
+}
+
+// MouseReporting turns on xterm click tracking with SGR coordinates, which
+// SyncTERM understands too. Terminals without a mouse ignore it.
+func (r *ansiRenderer) MouseReporting(on bool) {
+	if on {
+		r.w.WriteString(Esc + "?1000h" + Esc + "?1006h")
+	} else {
+		r.w.WriteString(Esc + "?1006l" + Esc + "?1000l")
+	}
+}
+
+// BracketedPaste turns on xterm's bracketed paste mode. Terminals that
+// don't have it ignore the request, and pastes look like typing.
+func (r *ansiRenderer) BracketedPaste(on bool) {
+	if on {
+		r.w.WriteString(Esc + "?2004h")
+	} else {
+		r.w.WriteString(Esc + "?2004l")
+	}
+}
+
+func (r *ansiRenderer) Flush() error {
+	return r.w.Flush()
+}
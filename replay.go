@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// runReplay is the `dsyp replay` command: it plays a recorded session back
+// in the terminal, as fast as it happened or faster.
+func runReplay(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	speedPtr := flags.Float64("speed", 1, "how many times faster than real time to play")
+	idlePtr := flags.Duration("idle", 2*time.Second, "longest pause to keep, at playing speed (0 keeps them all)")
+	fromPtr := flags.Duration("from", 0, "skip ahead to this far into the session, in recorded time")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: dsyp replay [-speed n] [-idle d] [-from d] file.cast")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *speedPtr <= 0 {
+		return errors.New("speed must be more than 0")
+	}
+
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return replay(f, os.Stdout, *speedPtr, *idlePtr, *fromPtr)
+}
+
+// replay plays the output of an asciicast to w, pausing on the clock
+// between bursts. Up to from it runs on a manual clock of its own, so the
+// screen is built up in an instant.
+func replay(r io.Reader, w io.Writer, speed float64, idle, from time.Duration) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	if !scanner.Scan() {
+		return errors.New("empty recording")
+	}
+	var header asciicastHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.Version != 2 {
+		return errors.New("not an asciicast v2 recording")
+	}
+
+	out := bufio.NewWriter(w)
+	defer out.Flush()
+	ahead := newManualClock(clock.Now())
+	var last float64
+	for scanner.Scan() {
+		var event []interface{}
+		if json.Unmarshal(scanner.Bytes(), &event) != nil || len(event) != 3 {
+			continue
+		}
+		at, _ := event[0].(float64)
+		kind, _ := event[1].(string)
+		text, _ := event[2].(string)
+		if kind != "o" {
+			continue
+		}
+
+		pause := time.Duration((at - last) / speed * float64(time.Second))
+		if idle > 0 && pause > idle {
+			pause = idle
+		}
+		last = at
+		var c Clock = clock
+		if time.Duration(at*float64(time.Second)) < from {
+			c = ahead
+		}
+		if pause > 0 {
+			out.Flush()
+			c.Sleep(pause)
+		}
+		out.WriteString(text)
+	}
+	out.WriteString(Reset + "\r\n")
+	return scanner.Err()
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplay(t *testing.T) {
+	cast := `{"version":2,"width":80,"height":25}
+[0.5,"o","a"]
+[1.0,"i","x"]
+[3.0,"o","b"]
+[13.0,"o","c"]
+`
+	tests := []struct {
+		name  string
+		speed float64
+		idle  time.Duration
+		from  time.Duration
+		want  time.Duration // time spent waiting on the clock
+	}{
+		{"real time", 1, 0, 0, 13 * time.Second},
+		{"double speed", 2, 0, 0, 6500 * time.Millisecond},
+		{"long pauses cut", 1, 2 * time.Second, 0, 4500 * time.Millisecond},
+		{"skip ahead", 1, 0, 5 * time.Second, 10 * time.Second},
+		{"skip it all", 1, 0, time.Minute, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := useManualClock(t)
+			start := c.Now()
+			var out bytes.Buffer
+			if err := replay(strings.NewReader(cast), &out, tt.speed, tt.idle, tt.from); err != nil {
+				t.Fatal(err)
+			}
+			if got := out.String(); got != "abc"+Reset+"\r\n" {
+				t.Errorf("played %q", got)
+			}
+			if got := c.Now().Sub(start); got != tt.want {
+				t.Errorf("waited %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplayNotACast(t *testing.T) {
+	for _, in := range []string{"", "hello\n", `{"version":1}` + "\n"} {
+		if err := replay(strings.NewReader(in), &bytes.Buffer{}, 1, 0, 0); err == nil {
+			t.Errorf("replayed %q", in)
+		}
+	}
+}
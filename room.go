@@ -0,0 +1,115 @@
+package main
+
+import "strings"
+
+// roomCommand carries out the commands that get the player about the room:
+// the door, the toilet and their pants, as the original had them. It
+// reports whether it knew the command.
+func (g *Game) roomCommand(words []string) bool {
+	s := &g.GameState
+	has := func(word string) bool { return containsWordFromList(word, words) }
+	hasAny := func(list []string) bool {
+		for _, word := range list {
+			if has(word) {
+				return true
+			}
+		}
+		return false
+	}
+	first := words[0]
+
+	var text string
+	did := "" // what happened, in the past tense; empty if it was refused
+	switch {
+	case hasAny(removeVerbs) && hasAny(pantsNouns):
+		switch {
+		case !s.Standing:
+			text = "Get off the toilet first."
+		case s.Pants:
+			s.Pants = false
+			text, did = "You remove your pants.", "took pants off"
+		default:
+			text = "Your pants are already off."
+		}
+	case hasAny(wearVerbs) && hasAny(pantsNouns):
+		switch {
+		case !s.Standing:
+			text = "Get off the toilet first."
+		case s.Pants:
+			text = "Your pants are already on."
+		default:
+			s.Pants = true
+			text, did = "You don't know why, but you put your pants back on.", "put pants on"
+		}
+	case (containsWordFromList(first, openVerbs) || containsWordFromList(first, pullVerbs)) && has("door"):
+		switch {
+		case g.doorOpens(first):
+			s.Door = true
+			text, did = "Oh right...", strings.ToLower(first)+"ed door"
+		case containsWordFromList(first, openVerbs):
+			text = "You try pushing the door open but it won't budge."
+		default:
+			text = "You pull at the door but it won't budge."
+		}
+	case containsWordFromList(first, closeVerbs) && has("door"):
+		switch {
+		case !s.Standing:
+			text = "You're sitting on the can. You can't reach the door."
+		case s.Door:
+			s.Door = false
+			text, did = "You close the door. But you still need to take a shit.", "closed door"
+		default:
+			text = "The door is already closed."
+		}
+	case (has("sit") && has("toilet")) || (containsWordFromList(first, moveVerbs) && (has("toilet") || hasAny(bathroomNouns))):
+		switch {
+		case !s.Standing:
+			text = "You're already sitting on the toilet."
+		case !s.Door:
+			text = "You quietly try to sit on the toilet with the door closed but your efforts are in vain."
+		case !g.takeToilet():
+			text = "Somebody beat you to it! The toilet's taken."
+		default:
+			s.Standing = false
+			text, did = "You sit on the toilet.", "sat on toilet"
+		}
+	case containsWordFromList(first, eatVerbs) && hasAny(pillsNouns):
+		if s.Pills {
+			text = "You already ate the pills."
+		} else {
+			s.Pills = true
+			s.PillTimer = g.difficulty().PillDelay
+			text, did = "You eat the pills. Hopefully they'll start working in time.", "ate pills"
+		}
+	case has("stand"):
+		if s.Standing {
+			text = "You stand up even more than before."
+		} else {
+			s.Standing = true
+			g.leaveToilet()
+			text, did = "You stand up.", "stood up"
+		}
+	default:
+		return false
+	}
+
+	// Awards look for what was done in the input buffer
+	if did != "" {
+		g.UserInputBuffer = append(g.UserInputBuffer, strings.Join(words, " "))
+		g.step(did)
+	}
+	g.message(BgBlue + CyanHi + text + Reset)
+	g.resetInput()
+	return true
+}
+
+// resetRoom puts the player back where a round starts: standing by the
+// closed door, pants on, pills in their pocket.
+func (g *Game) resetRoom() {
+	g.GameState.Pills = false
+	g.GameState.PillTimer = 0
+	g.GameState.Door = false
+	g.GameState.Pants = true
+	g.GameState.Standing = true
+	g.GameState.FartedLightly = false
+}
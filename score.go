@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Ending is how a round finished.
+type Ending string
+
+const (
+	EndingToilet      Ending = "toilet"       // shat in the toilet
+	EndingFloor       Ending = "floor"        // shat on the floor, pants off
+	EndingPillsWorked Ending = "pills"        // the pills kicked in
+	EndingToiletPants Ending = "toilet-pants" // on the toilet with pants on
+	EndingPants       Ending = "pants"        // shat your pants
+	EndingQuit        Ending = "quit"         // gave up
+)
+
+// endingScores is what each ending is worth before the bonuses.
+var endingScores = map[Ending]int{
+	EndingToilet:      1000,
+	EndingFloor:       600,
+	EndingPillsWorked: 800,
+	EndingToiletPants: 200,
+	EndingPants:       100,
+	EndingQuit:        0,
+}
+
+// endingMessages tell the player how the round ended.
+var endingMessages = map[Ending]string{
+	EndingToilet:      "You shit in the toilet! Congratulations!",
+	EndingFloor:       "You couldn't hold it anymore, you had to shit! Good thing your pants were off.",
+	EndingPillsWorked: "The pills worked! You didn't shit your pants. Congratulations!",
+	EndingToiletPants: "You forgot to take your pants off! You just shit your pants!",
+	EndingPants:       "You couldn't hold it anymore, you just shit your pants!",
+	EndingQuit:        "You gave up.",
+}
+
+// Won reports whether the ending counts as a win.
+func (e Ending) Won() bool {
+	return e == EndingToilet || e == EndingFloor || e == EndingPillsWorked
+}
+
+// Scoring rules on top of the ending
+const (
+	pointsPerSecondLeft = 10
+	efficiencyBonus     = 200 // for a round with no commands, less per command
+	pointsPerCommand    = 20
+	pointsPerFart       = 50
+	pantsOffBonus       = 100
+)
+
+// ScoreLine is one part of a score, for the breakdown.
+type ScoreLine struct {
+	Label  string
+	Points int
+}
+
+// Run is the record of one finished round.
+type Run struct {
+	Alias      string
+	Node       int
+	Difficulty string
+	Daily      string // date of the daily challenge this was, if it was one
+	Ending     Ending
+	Score      int
+	Breakdown  []ScoreLine
+	TimeLeft   time.Duration
+	Elapsed    time.Duration // how long the round took
+	Commands   int
+	Farts      int
+	PantsOff   bool
+	Pasted     bool // not eligible for leaderboards
+	Awards     []string
+	Timeline   []Step `json:",omitempty"`
+	When       time.Time
+}
+
+// shitEnding is where it ends up when the player lets go: it depends on
+// whether they're on the toilet and whether their pants are off.
+func (g *Game) shitEnding() Ending {
+	switch {
+	case g.GameState.Standing && g.GameState.Pants:
+		return EndingPants
+	case g.GameState.Standing:
+		return EndingFloor
+	case g.GameState.Pants:
+		return EndingToiletPants
+	}
+	return EndingToilet
+}
+
+// finishRun ends the round with the given ending and scores it.
+func (g *Game) finishRun(ending Ending) *Run {
+	return g.saveRun(g.stopRun(ending))
+}
+
+// stopRun notes how the round stood when it ended, before anything like an
+// award screen can keep the player waiting.
+func (g *Game) stopRun(ending Ending) *Run {
+	run := &Run{
+		Alias:      g.User.Alias,
+		Node:       g.User.NodeNum,
+		Difficulty: g.GameState.Difficulty,
+		Ending:     ending,
+		TimeLeft:   g.GameState.RemainingTime,
+		Commands:   g.GameState.Commands,
+		Farts:      g.GameState.Farts,
+		PantsOff:   !g.GameState.Pants,
+		Pasted:     g.GameState.Pasted,
+		Timeline:   g.GameState.Timeline,
+		When:       clock.Now(),
+	}
+	if g.challenge != nil {
+		run.Daily = g.challenge.Date
+	}
+	run.Elapsed = run.When.Sub(g.GameState.Started)
+	return run
+}
+
+// saveRun scores a stopped run with the awards the player holds now, and
+// records and reports it.
+func (g *Game) saveRun(run *Run) *Run {
+	for _, award := range awards {
+		if g.User.Awards[award.ID] {
+			run.Awards = append(run.Awards, award.ID)
+		}
+	}
+	run.Breakdown = scoreRun(run)
+	for _, line := range run.Breakdown {
+		run.Score += line.Points
+	}
+	if run.Score < 0 {
+		run.Score = 0
+	}
+	g.lastRun = run
+	past, err := loadRuns()
+	if err != nil {
+		inputLog(LogLevelError, "SysOp", "Failed to read the leaderboard: "+err.Error())
+	}
+	if err := recordRun(run); err != nil {
+		inputLog(LogLevelError, "SysOp", "Failed to record the run: "+err.Error())
+	}
+	reportRun(run, past)
+	g.announceRun(run)
+	g.postRaceState()
+	return run
+}
+
+// scoreRun works out the parts of a run's score. Only wins earn bonuses,
+// so losing early can't outscore losing late.
+func scoreRun(r *Run) []ScoreLine {
+	lines := []ScoreLine{{"Ending: " + string(r.Ending), endingScores[r.Ending]}}
+	if !r.Ending.Won() {
+		return lines
+	}
+	seconds := int(r.TimeLeft / time.Second)
+	lines = append(lines, ScoreLine{fmt.Sprintf("Time left: %d seconds", seconds), seconds * pointsPerSecondLeft})
+	if bonus := efficiencyBonus - r.Commands*pointsPerCommand; bonus > 0 {
+		lines = append(lines, ScoreLine{fmt.Sprintf("Efficiency: %d commands", r.Commands), bonus})
+	}
+	if r.Farts > 0 {
+		lines = append(lines, ScoreLine{fmt.Sprintf("Farts: %d", r.Farts), -r.Farts * pointsPerFart})
+	}
+	if r.PantsOff {
+		lines = append(lines, ScoreLine{"Pants off", pantsOffBonus})
+	}
+	return lines
+}
+
+// showScore prints the last run's score with its breakdown.
+func (g *Game) showScore() {
+	run := g.lastRun
+	if run == nil {
+		return
+	}
+	Println("")
+	for _, line := range run.Breakdown {
+		Printf(Cyan+"%-32s"+WhiteHi+"%6d"+Reset+"\n", line.Label, line.Points)
+	}
+	Printf(YellowHi+"%-32s%6d"+Reset+"\n", "SCORE ("+run.Difficulty+")", run.Score)
+	if run.Pasted {
+		Println(RedHi + "Pasted run: not eligible for the leaderboard." + Reset)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScoreRun(t *testing.T) {
+	tests := []struct {
+		name string
+		run  Run
+		want int
+	}{
+		{"toilet, nothing else", Run{Ending: EndingToilet, Commands: 10}, 1000},
+		{"time left", Run{Ending: EndingToilet, Commands: 10, TimeLeft: 12 * time.Second}, 1000 + 120},
+		{"part seconds don't count", Run{Ending: EndingToilet, Commands: 10, TimeLeft: 1900 * time.Millisecond}, 1000 + 10},
+		{"few commands", Run{Ending: EndingToilet, Commands: 3}, 1000 + 200 - 60},
+		{"farts cost", Run{Ending: EndingFloor, Commands: 10, Farts: 2}, 600 - 100},
+		{"pants off", Run{Ending: EndingPillsWorked, Commands: 10, PantsOff: true}, 800 + 100},
+		{"losses get no bonuses", Run{Ending: EndingPants, TimeLeft: 30 * time.Second, PantsOff: true}, 100},
+		{"quitting", Run{Ending: EndingQuit, TimeLeft: 30 * time.Second}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := 0
+			for _, line := range scoreRun(&tt.run) {
+				got += line.Points
+			}
+			if got != tt.want {
+				t.Errorf("scored %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShitEnding(t *testing.T) {
+	tests := []struct {
+		standing, pants bool
+		want            Ending
+	}{
+		{true, true, EndingPants},
+		{true, false, EndingFloor},
+		{false, true, EndingToiletPants},
+		{false, false, EndingToilet},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.want), func(t *testing.T) {
+			g := newTestGame(t)
+			g.GameState.Standing, g.GameState.Pants = tt.standing, tt.pants
+			if got := g.shitEnding(); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunStopsBeforeAwards(t *testing.T) {
+	c := useManualClock(t)
+	g := newTestGame(t)
+	g.GameState.Started = clock.Now()
+	g.GameState.RemainingTime = 37 * time.Second
+	g.GameState.Standing, g.GameState.Pants = false, false
+
+	c.Advance(3 * time.Second)
+	run := g.stopRun(g.shitEnding())
+	c.Advance(10 * time.Second) // reading the award screen
+	g.GameState.RemainingTime = 0
+	g.saveRun(run)
+
+	if run.Elapsed != 3*time.Second {
+		t.Errorf("elapsed %v, want 3s", run.Elapsed)
+	}
+	if run.TimeLeft != 37*time.Second {
+		t.Errorf("time left %v, want 37s", run.TimeLeft)
+	}
+	runs, err := loadRuns()
+	if err != nil || len(runs) != 1 || runs[0].Elapsed != 3*time.Second {
+		t.Errorf("recorded %v, %v, want one run of 3s", runs, err)
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import "sync"
+
+// Cell is one character position on the screen.
+type Cell struct {
+	Ch   rune
+	Attr Attr
+}
+
+var blankCell = Cell{Ch: ' ', Attr: defaultAttr}
+
+// same reports whether two cells look the same to the caller. Spaces only
+// show their background, so the foreground doesn't matter for them.
+func (c Cell) same(o Cell) bool {
+	if c.Ch == ' ' && o.Ch == ' ' {
+		return c.Attr.Bg == o.Attr.Bg
+	}
+	return c == o
+}
+
+// Screen is an in-memory copy of the caller's screen. Everything the door
+// draws goes into its cells; Flush then sends only the cells that differ
+// from what the terminal already shows.
+type Screen struct {
+	W, H  int
+	r     Renderer
+	cells []Cell // what we want on screen
+	sent  []Cell // what the terminal has
+	back  []Cell // the art behind the text, for Restore
+	st    ansiState
+
+	synced     bool // false until the terminal has been cleared once
+	cleared    bool // Clear was called since the last Flush
+	cursorOn   bool
+	cursorSent bool
+	lastAttr   Attr
+	attrKnown  bool
+	mutex      sync.Mutex
+}
+
+// screen is what the door draws on; it flushes to term.
+var screen = NewScreen(term)
+
+// NewScreen returns a blank screen the size of r.
+func NewScreen(r Renderer) *Screen {
+	s := &Screen{
+		W:        r.Width(),
+		H:        r.Height(),
+		r:        r,
+		st:       newAnsiState(r.Width(), r.Height()),
+		cursorOn: true,
+	}
+	s.cells = make([]Cell, s.W*s.H)
+	s.sent = make([]Cell, s.W*s.H)
+	s.back = make([]Cell, s.W*s.H)
+	for i := range s.cells {
+		s.cells[i] = blankCell
+		s.back[i] = blankCell
+	}
+	return s
+}
+
+// Clear blanks every cell, and the background, and homes the cursor.
+func (s *Screen) Clear() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.clear()
+	for i := range s.back {
+		s.back[i] = blankCell
+	}
+	s.st.X, s.st.Y = 1, 1
+}
+
+// SaveBackground remembers what's on screen now (normally freshly drawn
+// art) so text drawn over it can later be wiped with Restore.
+func (s *Screen) SaveBackground() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	copy(s.back, s.cells)
+}
+
+// Restore puts the saved background back in a w by h rectangle at (x,y).
+func (s *Screen) Restore(x, y, w, h int) {
+	s.RestoreShifted(x, y, w, h, 0)
+}
+
+// RestoreShifted is Restore with the background moved up by shift rows,
+// so row y shows what was saved for row y+shift.
+func (s *Screen) RestoreShifted(x, y, w, h, shift int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for row := y; row < y+h; row++ {
+		src := row + shift
+		for col := x; col < x+w; col++ {
+			if col < 1 || col > s.W || row < 1 || row > s.H {
+				continue
+			}
+			i := (row-1)*s.W + col - 1
+			if src >= 1 && src <= s.H {
+				s.cells[i] = s.back[(src-1)*s.W+col-1]
+			} else {
+				s.cells[i] = blankCell
+			}
+		}
+	}
+}
+
+// MoveTo places the drawing cursor, which is also where the terminal's
+// cursor is left after a Flush.
+func (s *Screen) MoveTo(x, y int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.st.MoveTo(x, y)
+}
+
+// Cursor returns the drawing cursor position.
+func (s *Screen) Cursor() (int, int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.st.X, s.st.Y
+}
+
+// Write draws text, which may carry ANSI color and cursor sequences, at the
+// cursor and advances it.
+func (s *Screen) Write(text string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.st.feed(text, s)
+}
+
+// PrintAt draws text at (x,y) without moving the cursor or changing the
+// current colors, so background updates don't disturb what the player is
+// typing.
+func (s *Screen) PrintAt(x, y int, text string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	saved := s.st
+	s.st.MoveTo(x, y)
+	s.st.feed(text, s)
+	s.st.X, s.st.Y, s.st.Attr = saved.X, saved.Y, saved.Attr
+}
+
+// Fill blanks a w by h rectangle at (x,y) with spaces in attribute a.
+func (s *Screen) Fill(x, y, w, h int, a Attr) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for row := y; row < y+h; row++ {
+		for col := x; col < x+w; col++ {
+			s.putCell(col, row, ' ', a)
+		}
+	}
+}
+
+// ClearLine blanks row y from column x to the right edge.
+func (s *Screen) ClearLine(x, y int, a Attr) {
+	s.Fill(x, y, s.W-x+1, 1, a)
+}
+
+// ShowCursor sets whether the terminal's cursor is visible after a Flush.
+func (s *Screen) ShowCursor(show bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.cursorOn = show
+}
+
+// Invalidate forgets what the terminal shows, so the next Flush clears it
+// and redraws everything.
+func (s *Screen) Invalidate() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.synced = false
+}
+
+// Flush sends the cells that changed since the last Flush and leaves the
+// terminal's cursor at the drawing cursor.
+func (s *Screen) Flush() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// After a Clear that touched much of the screen, clearing the terminal
+	// and drawing what's left is cheaper than sending all the blanks.
+	if !s.synced || (s.cleared && s.changes() > len(s.cells)/4) {
+		s.r.Clear()
+		for i := range s.sent {
+			s.sent[i] = blankCell
+		}
+		s.synced = true
+		s.attrKnown = false
+		s.cursorSent = !s.cursorOn
+	}
+	s.cleared = false
+
+	tx, ty := -1, -1 // where the terminal's cursor is, if known
+	for y := 1; y <= s.H; y++ {
+		for x := 1; x <= s.W; x++ {
+			i := (y-1)*s.W + x - 1
+			c := s.cells[i]
+			if c.same(s.sent[i]) {
+				continue
+			}
+			if x != tx || y != ty {
+				s.r.MoveTo(x, y)
+			}
+			if !s.attrKnown || (c.Attr != s.lastAttr && !(c.Ch == ' ' && c.Attr.Bg == s.lastAttr.Bg)) {
+				s.r.SetAttr(c.Attr)
+				s.lastAttr, s.attrKnown = c.Attr, true
+			}
+			s.r.Write(string(c.Ch))
+			s.sent[i] = c
+
+			tx, ty = x+1, y
+			if tx > s.W {
+				tx = -1
+			}
+		}
+	}
+
+	cx, cy := clamp(s.st.X, 1, s.W), clamp(s.st.Y, 1, s.H)
+	if cx != tx || cy != ty {
+		s.r.MoveTo(cx, cy)
+	}
+	if s.cursorOn != s.cursorSent {
+		s.r.ShowCursor(s.cursorOn)
+		s.cursorSent = s.cursorOn
+	}
+	s.r.Flush()
+}
+
+// Repaint draws on r everything the terminal shows, then calls then before
+// anything more can be drawn, so r can pick up from there.
+func (s *Screen) Repaint(r Renderer, then func()) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	r.Clear()
+	var last Attr
+	known := false
+	for y := 1; y <= s.H; y++ {
+		r.MoveTo(1, y)
+		for x := 1; x <= s.W; x++ {
+			c := s.sent[(y-1)*s.W+x-1]
+			if !known || c.Attr != last {
+				r.SetAttr(c.Attr)
+				last, known = c.Attr, true
+			}
+			r.Write(string(c.Ch))
+		}
+	}
+	r.SetAttr(s.lastAttr)
+	r.MoveTo(clamp(s.st.X, 1, s.W), clamp(s.st.Y, 1, s.H))
+	r.ShowCursor(s.cursorSent)
+	r.Flush()
+	then()
+}
+
+// changes counts cells that differ from what was sent.
+func (s *Screen) changes() int {
+	n := 0
+	for i, c := range s.cells {
+		if !c.same(s.sent[i]) {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Screen) putCell(x, y int, r rune, a Attr) {
+	if x < 1 || x > s.W || y < 1 || y > s.H {
+		return
+	}
+	s.cells[(y-1)*s.W+x-1] = Cell{Ch: r, Attr: a}
+}
+
+func (s *Screen) clear() {
+	for i := range s.cells {
+		s.cells[i] = blankCell
+	}
+	s.cleared = true
+}
+
+// Flush sends pending drawing to the caller.
+func Flush() {
+	screen.Flush()
+}
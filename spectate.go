@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// spectateDir holds a socket for each node a session is on, under dataDir.
+const spectateDir = "spectate"
+
+// spectatorWait is how long a spectator gets to take output before it's
+// dropped, so a stuck one can't hold up the player.
+const spectatorWait = 500 * time.Millisecond
+
+// Spectating settings, from the command line
+var (
+	spectateSessions = true
+	showSpectators   = true // tell the player when the sysop is watching
+)
+
+// spectating mirrors the session to the sysop, if it can be watched.
+var spectating *mirror
+
+// mirror copies a session's output to everyone attached to the node's
+// socket. They only ever get output: nothing is read from them.
+type mirror struct {
+	mutex sync.Mutex
+	l     net.Listener
+	path  string
+	conns []net.Conn
+	cp437 bool
+}
+
+func spectatePath(node int) string {
+	return dataPath(filepath.Join(spectateDir, strconv.Itoa(node)+".sock"))
+}
+
+// startMirror opens the node's socket. PETSCII sessions can't be watched:
+// a terminal can't show them.
+func startMirror(u User) (*mirror, error) {
+	if u.Emulation == EmulationPETSCII {
+		return nil, nil
+	}
+	path := spectatePath(u.NodeNum)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	// A session that died without closing leaves its socket behind
+	os.Remove(path)
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	// Only the sysop's account may watch, and see what the caller types
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return &mirror{l: l, path: path, cp437: u.Encoding() == EncodingCP437}, nil
+}
+
+// serve attaches spectators as they come, each with the screen as it is.
+func (m *mirror) serve() {
+	for {
+		conn, err := m.l.Accept()
+		if err != nil {
+			return
+		}
+		var buf bytes.Buffer
+		screen.Repaint(newANSIRenderer(&buf, false, screen.W, screen.H), func() {
+			m.mutex.Lock()
+			defer m.mutex.Unlock()
+			conn.SetWriteDeadline(time.Now().Add(spectatorWait))
+			if _, err := conn.Write(buf.Bytes()); err != nil {
+				conn.Close()
+				return
+			}
+			m.conns = append(m.conns, conn)
+		})
+	}
+}
+
+// Write passes output on to the spectators, as UTF-8.
+func (m *mirror) Write(p []byte) (int, error) {
+	text := p
+	if m.cp437 {
+		decoded, _ := charmap.CodePage437.NewDecoder().Bytes(p)
+		text = decoded
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	kept := m.conns[:0]
+	for _, conn := range m.conns {
+		conn.SetWriteDeadline(time.Now().Add(spectatorWait))
+		if _, err := conn.Write(text); err != nil {
+			conn.Close()
+			continue
+		}
+		kept = append(kept, conn)
+	}
+	m.conns = kept
+	return len(p), nil
+}
+
+// Watchers counts the spectators attached.
+func (m *mirror) Watchers() int {
+	if m == nil {
+		return 0
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.conns)
+}
+
+// Close sends the spectators away and removes the socket.
+func (m *mirror) Close() error {
+	err := m.l.Close()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, conn := range m.conns {
+		conn.Close()
+	}
+	m.conns = nil
+	os.Remove(m.path)
+	return err
+}
+
+// drawWatching shows the player whether the sysop is watching, on the
+// screens with a ticker line.
+func (g *Game) drawWatching() {
+	if !showSpectators || (g.GameState.AppState != stateMainMenu && g.GameState.AppState != statePlaying) {
+		return
+	}
+	if spectating.Watchers() > 0 {
+		g.layout.Watch.Print(BgMagenta + WhiteHi + watchLabel(artWidth(g.User.W)) + Reset)
+	} else {
+		g.layout.Watch.Clear()
+	}
+}
+
+// watchLabel is what the watch region says, at a width that fits the art.
+func watchLabel(w int) string {
+	if w < 80 {
+		return " SYSOP "
+	}
+	return " SYSOP WATCHING "
+}
+
+// runSpectate is the `dsyp spectate` command: it shows a node's session
+// live, without taking any part in it.
+func runSpectate(args []string) error {
+	flags := flag.NewFlagSet("spectate", flag.ExitOnError)
+	nodePtr := flags.Int("node", 0, "node to watch")
+	dataPtr := flags.String("data", dataDir, "directory for files shared by all nodes")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: dsyp spectate -node n [-data dir]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if *nodePtr <= 0 || flags.NArg() != 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	dataDir = *dataPtr
+
+	conn, err := net.Dial("unix", spectatePath(*nodePtr))
+	if err != nil {
+		return errors.New("nobody is on node " + strconv.Itoa(*nodePtr))
+	}
+	defer conn.Close()
+
+	// Ctrl-C stops watching; the caller carries on
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupt
+		conn.Close()
+	}()
+
+	io.Copy(os.Stdout, conn)
+	// Undo the modes the door asks the caller's terminal for
+	fmt.Print(Reset + Esc + "?1006l" + Esc + "?1000l" + Esc + "?2004l" + Esc + "?25h\r\n")
+	fmt.Println("Stopped watching node " + strconv.Itoa(*nodePtr) + ".")
+	return nil
+}
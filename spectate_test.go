@@ -0,0 +1,22 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMirrorSocketPrivate(t *testing.T) {
+	useDataDir(t)
+	m, err := startMirror(User{NodeNum: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	info, err := os.Stat(spectatePath(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("socket mode %o, want 600", perm)
+	}
+}
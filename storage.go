@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"golang.org/x/sys/unix"
+)
+
+// dataDir holds the files shared by every node: scores, news and the like.
+var dataDir = "data"
+
+// dataPath returns where a shared file lives.
+func dataPath(name string) string {
+	return filepath.Join(dataDir, name)
+}
+
+// withLockedFile opens path and holds an flock on it while fn runs, so
+// nodes don't trip over each other. Writers take an exclusive lock,
+// readers a shared one.
+func withLockedFile(path string, flag int, fn func(f *os.File) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, flag|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	how := unix.LOCK_SH
+	if flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+		how = unix.LOCK_EX
+	}
+	if err := unix.Flock(int(f.Fd()), how); err != nil {
+		return err
+	}
+	defer unix.Flock(int(f.Fd()), unix.LOCK_UN)
+	return fn(f)
+}
+
+// appendJSON adds v to the end of a file of JSON lines.
+func appendJSON(path string, v interface{}) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return withLockedFile(path, os.O_WRONLY|os.O_APPEND, func(f *os.File) error {
+		_, err := f.Write(append(line, '\n'))
+		return err
+	})
+}
+
+// readJSONLines calls fn with each line of a file of JSON lines. Lines that
+// don't parse, like one cut short by a crash, are skipped.
+func readJSONLines(path string, fn func(line []byte) error) error {
+	return withLockedFile(path, os.O_RDONLY, func(f *os.File) error {
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			if !json.Valid(scanner.Bytes()) {
+				continue
+			}
+			if err := fn(scanner.Bytes()); err != nil {
+				return err
+			}
+		}
+		return scanner.Err()
+	})
+}
+
+// loadJSON reads a file holding one JSON value into v. A missing file
+// leaves v alone.
+func loadJSON(path string, v interface{}) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	return withLockedFile(path, os.O_RDONLY, func(f *os.File) error {
+		return json.NewDecoder(f).Decode(v)
+	})
+}
+
+// saveJSON replaces the contents of a file with v.
+func saveJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return withLockedFile(path, os.O_RDWR, func(f *os.File) error {
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		_, err := f.Write(append(data, '\n'))
+		return err
+	})
+}
+
+// fileName turns a player's alias into something safe to name a file with.
+func fileName(alias string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' {
+			return unicode.ToLower(r)
+		}
+		return '_'
+	}, alias)
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Telnet commands and the options we negotiate (RFC 854, 857, 858, 1073)
+const (
+	telnetSE   = 240
+	telnetSB   = 250
+	telnetWILL = 251
+	telnetWONT = 252
+	telnetDO   = 253
+	telnetDONT = 254
+	telnetIAC  = 255
+
+	telnetOptEcho = 1
+	telnetOptSGA  = 3
+	telnetOptNAWS = 31
+)
+
+// States of the telnet parser
+const (
+	telnetData = iota
+	telnetCommand
+	telnetOption
+	telnetSub
+	telnetSubIAC
+)
+
+// telnetReader strips telnet negotiation out of what the caller sends and
+// answers it, for doors started straight on a telnet socket by inetd or a
+// minimal BBS. Until it is switched on, or sees the caller negotiate, it
+// passes everything through untouched.
+type telnetReader struct {
+	r io.Reader
+	w *telnetWriter // where answers go, in turn with the door's output
+
+	active  bool
+	state   int
+	cmd     byte
+	sub     []byte
+	lastCR  bool
+	held    []byte        // data read while waiting for the window size
+	us, him map[byte]bool // options enabled on our side and the caller's
+
+	mutex         sync.Mutex
+	width, height int
+}
+
+// telnet wraps the door's standard input, and telnetOut its standard
+// output.
+var telnet, telnetOut = newTelnet(os.Stdin, os.Stdout)
+
+func newTelnet(r io.Reader, w io.Writer) (*telnetReader, *telnetWriter) {
+	t := &telnetReader{r: r, us: map[byte]bool{}, him: map[byte]bool{}}
+	t.w = &telnetWriter{w: w, t: t}
+	return t, t.w
+}
+
+// onSocket reports whether the door's stdin is a network socket, as when
+// inetd hands it the caller's connection. A pty or a pipe isn't telnet.
+func onSocket() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeSocket != 0
+}
+
+// Start switches the filter on and offers the options a door needs: we
+// echo, we don't wait for go-aheads, and the caller should tell us its
+// window size.
+func (t *telnetReader) Start() {
+	t.setActive()
+	t.us[telnetOptEcho] = true
+	t.us[telnetOptSGA] = true
+	t.him[telnetOptNAWS] = true
+	t.w.command([]byte{
+		telnetIAC, telnetWILL, telnetOptEcho,
+		telnetIAC, telnetWILL, telnetOptSGA,
+		telnetIAC, telnetDO, telnetOptNAWS,
+	})
+}
+
+// Active reports whether the caller is talking telnet. Output asks from
+// other goroutines than the one reading.
+func (t *telnetReader) Active() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.active
+}
+
+func (t *telnetReader) setActive() {
+	t.mutex.Lock()
+	t.active = true
+	t.mutex.Unlock()
+}
+
+// Size returns the window size the caller reported, or zeros if it hasn't.
+func (t *telnetReader) Size() (int, int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.width, t.height
+}
+
+// AwaitSize reads negotiation until the caller reports its window size or
+// timeout passes. Anything typed meanwhile is kept for Read.
+func (t *telnetReader) AwaitSize(timeout time.Duration) {
+	fd := int(os.Stdin.Fd())
+	deadline := time.Now().Add(timeout)
+	buf := make([]byte, 256)
+	for {
+		if w, _ := t.Size(); w > 0 {
+			return
+		}
+		left := time.Until(deadline)
+		if left <= 0 {
+			return
+		}
+		fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, int(left/time.Millisecond)+1)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil || n == 0 {
+			return
+		}
+		n, err = t.r.Read(buf)
+		if err != nil {
+			return
+		}
+		t.held = append(t.held, t.filter(buf[:n])...)
+	}
+}
+
+// Read returns the caller's data with the telnet protocol taken out.
+func (t *telnetReader) Read(p []byte) (int, error) {
+	if len(t.held) > 0 {
+		n := copy(p, t.held)
+		t.held = t.held[n:]
+		return n, nil
+	}
+	for {
+		n, err := t.r.Read(p)
+		if n > 0 {
+			if out := t.filter(p[:n]); len(out) > 0 {
+				return copy(p, out), nil
+			}
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// filter runs the parser over in and returns the data bytes it holds.
+func (t *telnetReader) filter(in []byte) []byte {
+	var out []byte
+	for _, b := range in {
+		out = t.feed(b, out)
+	}
+	return out
+}
+
+func (t *telnetReader) feed(b byte, out []byte) []byte {
+	switch t.state {
+	case telnetData:
+		if b == telnetIAC {
+			t.state = telnetCommand
+			return out
+		}
+		if t.active {
+			// CR NUL and CR LF both mean Enter
+			wasCR := t.lastCR
+			t.lastCR = b == '\r'
+			if wasCR && (b == 0 || b == '\n') {
+				return out
+			}
+		}
+		return append(out, b)
+
+	case telnetCommand:
+		t.state = telnetData
+		if b < telnetSE {
+			if !t.active {
+				// Not telnet after all, just a 0xFF typed by the caller
+				return t.feed(b, append(out, telnetIAC))
+			}
+			return out
+		}
+		t.setActive()
+		switch b {
+		case telnetIAC:
+			return append(out, telnetIAC)
+		case telnetWILL, telnetWONT, telnetDO, telnetDONT:
+			t.cmd = b
+			t.state = telnetOption
+		case telnetSB:
+			t.sub = t.sub[:0]
+			t.state = telnetSub
+		}
+		// NOP, AYT, break and the rest need no answer from a door
+
+	case telnetOption:
+		t.state = telnetData
+		t.answer(t.cmd, b)
+
+	case telnetSub:
+		if b == telnetIAC {
+			t.state = telnetSubIAC
+		} else if len(t.sub) < 64 {
+			t.sub = append(t.sub, b)
+		}
+
+	case telnetSubIAC:
+		switch b {
+		case telnetSE:
+			t.state = telnetData
+			t.subnegotiation()
+		case telnetIAC:
+			t.state = telnetSub
+			t.sub = append(t.sub, telnetIAC)
+		default:
+			t.state = telnetData
+		}
+	}
+	return out
+}
+
+// answer replies to the caller's WILL, WONT, DO or DONT. Requests that
+// match how an option already stands get no reply, so the two sides can't
+// loop.
+func (t *telnetReader) answer(cmd, opt byte) {
+	switch cmd {
+	case telnetDO:
+		if opt == telnetOptEcho || opt == telnetOptSGA {
+			if !t.us[opt] {
+				t.us[opt] = true
+				t.send(telnetWILL, opt)
+			}
+		} else {
+			t.send(telnetWONT, opt)
+		}
+	case telnetDONT:
+		if t.us[opt] {
+			t.us[opt] = false
+			t.send(telnetWONT, opt)
+		}
+	case telnetWILL:
+		if opt == telnetOptNAWS {
+			if !t.him[opt] {
+				t.him[opt] = true
+				t.send(telnetDO, opt)
+			}
+		} else {
+			t.send(telnetDONT, opt)
+		}
+	case telnetWONT:
+		if t.him[opt] {
+			t.him[opt] = false
+			t.send(telnetDONT, opt)
+		}
+	}
+}
+
+// subnegotiation handles a finished IAC SB ... IAC SE. Only NAWS is used.
+func (t *telnetReader) subnegotiation() {
+	if len(t.sub) != 5 || t.sub[0] != telnetOptNAWS {
+		return
+	}
+	w := int(t.sub[1])<<8 | int(t.sub[2])
+	h := int(t.sub[3])<<8 | int(t.sub[4])
+	if w == 0 || h == 0 {
+		return // the caller doesn't know
+	}
+	t.mutex.Lock()
+	t.width, t.height = w, h
+	t.mutex.Unlock()
+}
+
+func (t *telnetReader) send(cmd, opt byte) {
+	t.w.command([]byte{telnetIAC, cmd, opt})
+}
+
+// telnetWriter escapes what the door sends once the caller is talking
+// telnet: a 0xFF goes out as IAC IAC, and a bare LF as CR LF. Answers to
+// the caller's negotiation go out through it too, one write at a time, so
+// they fall between the renderer's frames.
+type telnetWriter struct {
+	mutex  sync.Mutex
+	w      io.Writer
+	t      *telnetReader
+	lastCR bool
+}
+
+func (w *telnetWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if !w.t.Active() {
+		if len(p) > 0 {
+			w.lastCR = p[len(p)-1] == '\r'
+		}
+		return w.w.Write(p)
+	}
+	out := make([]byte, 0, len(p)+8)
+	for _, b := range p {
+		switch {
+		case b == telnetIAC:
+			out = append(out, telnetIAC, telnetIAC)
+		case b == '\n' && !w.lastCR:
+			out = append(out, '\r', '\n')
+		default:
+			out = append(out, b)
+		}
+		w.lastCR = b == '\r'
+	}
+	if _, err := w.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// command sends a telnet command as it is.
+func (w *telnetWriter) command(p []byte) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.w.Write(p)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTelnetFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		active     bool
+		in         []byte
+		want       []byte
+		answer     []byte
+		wantW      int
+		wantH      int
+		wantActive bool
+	}{
+		{name: "plain text passes", in: []byte("hi\r\n"), want: []byte("hi\r\n")},
+		{name: "0xFF typed before telnet", in: []byte{'a', 0xff, 'b'}, want: []byte{'a', 0xff, 'b'}},
+		{name: "CR LF is one Enter", active: true, in: []byte("a\r\nb\r\x00"), want: []byte("a\rb\r"), wantActive: true},
+		{name: "IAC IAC is a 0xFF", active: true, in: []byte{'a', 0xff, 0xff}, want: []byte{'a', 0xff}, wantActive: true},
+		{
+			name:       "window size",
+			in:         []byte{'x', telnetIAC, telnetSB, telnetOptNAWS, 0, 132, 0, 50, telnetIAC, telnetSE, 'y'},
+			want:       []byte("xy"),
+			wantW:      132,
+			wantH:      50,
+			wantActive: true,
+		},
+		{
+			name:       "escaped 0xFF in the window size",
+			in:         []byte{telnetIAC, telnetSB, telnetOptNAWS, 0, 0xff, 0xff, 0, 40, telnetIAC, telnetSE},
+			wantW:      255,
+			wantH:      40,
+			wantActive: true,
+		},
+		{
+			name:       "unknown size is ignored",
+			in:         []byte{telnetIAC, telnetSB, telnetOptNAWS, 0, 0, 0, 0, telnetIAC, telnetSE},
+			wantActive: true,
+		},
+		{
+			name:       "refuses options it doesn't do",
+			in:         []byte{telnetIAC, telnetDO, 24, telnetIAC, telnetWILL, 24},
+			answer:     []byte{telnetIAC, telnetWONT, 24, telnetIAC, telnetDONT, 24},
+			wantActive: true,
+		},
+		{
+			name:       "agrees to NAWS once",
+			in:         []byte{telnetIAC, telnetWILL, telnetOptNAWS, telnetIAC, telnetWILL, telnetOptNAWS},
+			answer:     []byte{telnetIAC, telnetDO, telnetOptNAWS},
+			wantActive: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var answers bytes.Buffer
+			tr, _ := newTelnet(nil, &answers)
+			tr.active = tt.active
+			got := tr.filter(tt.in)
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("data %q, want %q", got, tt.want)
+			}
+			if !bytes.Equal(answers.Bytes(), tt.answer) {
+				t.Errorf("answered %v, want %v", answers.Bytes(), tt.answer)
+			}
+			if w, h := tr.Size(); w != tt.wantW || h != tt.wantH {
+				t.Errorf("size %dx%d, want %dx%d", w, h, tt.wantW, tt.wantH)
+			}
+			if tr.Active() != tt.wantActive {
+				t.Errorf("active %v, want %v", tr.Active(), tt.wantActive)
+			}
+		})
+	}
+}
+
+func TestTelnetWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		active bool
+		writes []string
+		want   string
+	}{
+		{"untouched without telnet", false, []string{"a\nb\xff"}, "a\nb\xff"},
+		{"bare LF gets a CR", true, []string{"a\nb\r\n"}, "a\r\nb\r\n"},
+		{"CR in an earlier write", true, []string{"a\r", "\nb"}, "a\r\nb"},
+		{"0xFF is doubled", true, []string{"\xff\xfe"}, "\xff\xff\xfe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			tr, w := newTelnet(nil, &out)
+			tr.active = tt.active
+			for _, s := range tt.writes {
+				if n, err := w.Write([]byte(s)); n != len(s) || err != nil {
+					t.Fatalf("wrote %d of %d: %v", n, len(s), err)
+				}
+			}
+			if out.String() != tt.want {
+				t.Errorf("sent %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
@@ -1,13 +1,21 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 )
 
+// Ticker delivers a tick every Duration until it is stopped.
 type Ticker interface {
 	Duration() time.Duration
 	Tick()
+	// Ticks is the channel the ticks arrive on, for use in a select.
+	Ticks() <-chan time.Time
 	Stop()
 }
 
@@ -19,52 +27,290 @@ type ticker struct {
 }
 
 func (t *ticker) Tick()                   { <-t.C }
+func (t *ticker) Ticks() <-chan time.Time { return t.C }
 func (t *ticker) Duration() time.Duration { return t.d }
 
 func NewTicker(d time.Duration) Ticker {
 	return &ticker{time.NewTicker(d), d}
 }
 
-func (g *Game) timer(stopChan chan bool, inputChan chan byte) {
-	ticker := NewTicker(time.Second)
-	defer ticker.Stop()
+// Clock is where all game timing comes from: the countdown, scheduled
+// events and the pauses between screens.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+	Sleep(d time.Duration)
+}
+
+// clock is wall time in the door. Tests swap in a manual clock, so a round
+// plays out in an instant, and replay skips ahead on one.
+var clock Clock = wallClock{}
+
+type wallClock struct{}
 
+func (wallClock) Now() time.Time                   { return time.Now() }
+func (wallClock) NewTicker(d time.Duration) Ticker { return NewTicker(d) }
+func (wallClock) Sleep(d time.Duration)            { time.Sleep(d) }
+
+// manualClock only moves when told to, so a 40-second round can be played
+// in an instant. Sleeping on it moves it forward.
+type manualClock struct {
+	mutex   sync.Mutex
+	now     time.Time
+	tickers []*manualTicker
+}
+
+func newManualClock(start time.Time) *manualClock {
+	return &manualClock{now: start}
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *manualClock) NewTicker(d time.Duration) Ticker {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	t := &manualTicker{clock: c, c: make(chan time.Time, manualTickBuffer), d: d, next: c.now.Add(d)}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+func (c *manualClock) Sleep(d time.Duration) {
+	c.Advance(d)
+}
+
+// Advance moves the clock forward by d, delivering every tick that falls
+// due on the way.
+func (c *manualClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	end := c.now.Add(d)
 	for {
-		select {
-		case <-stopChan:
-			ticker.Stop()
-			return // Stop signal received, exit the timer
-		case <-g.GameState.DoneChan:
-			return // Game is done, exit the timer
-		case <-time.After(ticker.Duration()):
-			if g.GameState.RemainingTime > 0 {
-				g.GameState.RemainingTime -= time.Second
+		// Next tick of any live ticker, so ticks come out in time order
+		var next *manualTicker
+		for _, t := range c.tickers {
+			if !t.stopped && !t.next.After(end) && (next == nil || t.next.Before(next.next)) {
+				next = t
 			}
+		}
+		if next == nil {
+			break
+		}
+		c.now = next.next
+		select {
+		case next.c <- c.now:
+		default: // nobody is keeping up; drop it like time.Ticker does
+		}
+		next.next = next.next.Add(next.d)
+	}
+	c.now = end
+}
 
-			if g.GameState.RemainingTime < time.Second*20 {
-				// Specific logic when the timer is under 20 seconds
-				MoveCursor(2, 23)
-				fmt.Print(EraseLine)
-				fmt.Println(BgBlue + RedHi + "Hurry! You need to find a way to reduce the pressure in your gut." + Reset)
-			}
+// manualTickBuffer is how many ticks a manual ticker holds, so a big
+// Advance doesn't lose any while the game catches up.
+const manualTickBuffer = 1024
+
+type manualTicker struct {
+	clock   *manualClock
+	c       chan time.Time
+	d       time.Duration
+	next    time.Time
+	stopped bool
+}
+
+func (t *manualTicker) Tick()                   { <-t.c }
+func (t *manualTicker) Ticks() <-chan time.Time { return t.c }
+func (t *manualTicker) Duration() time.Duration { return t.d }
+
+func (t *manualTicker) Stop() {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	t.stopped = true
+}
+
+// timedEvent is something that happens when the countdown reaches left.
+// Time added to the clock puts it off.
+type timedEvent struct {
+	left time.Duration
+	fn   func()
+}
+
+// at schedules fn for when the countdown reaches left.
+func (g *Game) at(left time.Duration, fn func()) {
+	g.events = append(g.events, timedEvent{left, fn})
+}
+
+// runDue runs, in the order they were scheduled, the events whose time
+// has come.
+func (g *Game) runDue() {
+	var due []timedEvent
+	pending := g.events[:0]
+	for _, e := range g.events {
+		if g.GameState.RemainingTime <= e.left {
+			due = append(due, e)
+		} else {
+			pending = append(pending, e)
+		}
+	}
+	g.events = pending
+	for _, e := range due {
+		e.fn()
+	}
+}
 
-			// Timer update logic
-			MoveCursor(0, 0)
-			fmt.Print(Reset + "                            ")
-			MoveCursor(0, 0)
-			fmt.Printf(Reset+Green+" TIMER: %v"+Reset, g.GameState.RemainingTime)
-			fmt.Print(BgBlue + YellowHi)
+// UrgencyStage is a warning posted when the countdown reaches Left, unless
+// the player has farted by then.
+type UrgencyStage struct {
+	Left       time.Duration
+	Message    string
+	Color      string // color of the message
+	TimerColor string // color of the timer from then on
+}
 
-			// Restore the user's cursor position
-			MoveCursor(g.GameState.cursX, g.GameState.cursY)
+// urgencyStages are the warnings of a round, in the order they come. The
+// sysop can swap in their own with -urgency.
+var urgencyStages = []UrgencyStage{
+	{
+		Left:       19 * time.Second,
+		Message:    "You're running out of time. You need to find a way to reduce the pressure in your gut.",
+		Color:      YellowHi,
+		TimerColor: YellowHi,
+	},
+	{
+		Left:       4 * time.Second,
+		Message:    "OMG it's peeking its head! Do something about the gas build up!",
+		Color:      RedHi,
+		TimerColor: RedHi,
+	},
+}
 
-			if g.GameState.RemainingTime == 0 {
-				// Timer expired, call gameOver
-				close(g.GameState.DoneChan) // Signal all goroutines to stop
-				g.GameState.AppState = stateGameOver
+// colorNames are the colors an urgency file can ask for.
+var colorNames = map[string]string{
+	"red": Red, "green": Green, "yellow": Yellow, "blue": Blue,
+	"magenta": Magenta, "cyan": Cyan, "white": White,
+	"redhi": RedHi, "greenhi": GreenHi, "yellowhi": YellowHi, "bluehi": BlueHi,
+	"magentahi": MagentaHi, "cyanhi": CyanHi, "whitehi": WhiteHi,
+}
 
+// loadUrgency reads the warnings of a round from a sysop's file: a JSON
+// list of stages like
+//
+//	{"Left": "19s", "Message": "Hurry!", "Color": "yellowhi", "TimerColor": "yellowhi"}
+//
+// Color and TimerColor name one of colorNames.
+func loadUrgency(path string) ([]UrgencyStage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []struct{ Left, Message, Color, TimerColor string }
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	var stages []UrgencyStage
+	for i, e := range entries {
+		left, err := time.ParseDuration(e.Left)
+		if err != nil || left <= 0 {
+			return nil, fmt.Errorf("%s: stage %d: Left must be a time like \"19s\"", path, i+1)
+		}
+		color, ok := colorNames[strings.ToLower(e.Color)]
+		timerColor, timerOK := colorNames[strings.ToLower(e.TimerColor)]
+		if !ok || !timerOK {
+			return nil, fmt.Errorf("%s: stage %d: unknown color", path, i+1)
+		}
+		stages = append(stages, UrgencyStage{Left: left, Message: e.Message, Color: color, TimerColor: timerColor})
+	}
+	sort.SliceStable(stages, func(i, j int) bool { return stages[i].Left > stages[j].Left })
+	return stages, nil
+}
+
+// timerFrame is how often the timer display is redrawn, and timerCountUp
+// how much it climbs each frame when time is added, so added time rolls
+// up instead of jumping.
+const (
+	timerFrame   = 100 * time.Millisecond
+	timerCountUp = 3 * time.Second
+)
+
+// scheduleUrgency sets up the warnings for a new round.
+func (g *Game) scheduleUrgency() {
+	g.timerColor = Green
+	for _, stage := range urgencyStages {
+		stage := stage
+		g.at(stage.Left, func() {
+			if g.GameState.Farts > 0 {
 				return
 			}
+			g.timerColor = stage.TimerColor
+			g.message(BgBlue + stage.Color + stage.Message + Reset)
+		})
+	}
+}
+
+// tick advances the countdown by one second. When it runs out the round is
+// over at once, not on the player's next key.
+func (g *Game) tick(inputChan chan Key) {
+	if g.GameState.RemainingTime > 0 {
+		g.GameState.RemainingTime -= time.Second
+	}
+	g.runDue()
+	g.animateTimer()
+	g.updateRace()
+	g.drawGhost()
+
+	// Pills that kick in on the last second still save the day
+	if g.GameState.Pills && g.GameState.PillTimer > 0 {
+		g.GameState.PillTimer -= time.Second
+		if g.GameState.PillTimer <= 0 && g.GameState.AppState == statePlaying {
+			g.endRound(EndingPillsWorked, inputChan)
+			return
+		}
+	}
+
+	if g.GameState.RemainingTime == 0 && g.GameState.AppState == statePlaying {
+		g.endRound(g.shitEnding(), inputChan)
+	}
+}
+
+// endRound ends the round on the clock rather than on a command.
+func (g *Game) endRound(ending Ending, inputChan chan Key) {
+	g.events = nil
+	g.finishRun(ending)
+	g.GameState.AppState = stateGameOver
+	g.updateGameEnvironment(inputChan)
+}
+
+// animateTimer moves the displayed time one frame towards the real one:
+// straight down as the clock runs, rolling up when time is added.
+func (g *Game) animateTimer() {
+	shown := g.timerShown
+	switch {
+	case shown < g.GameState.RemainingTime:
+		shown += timerCountUp
+		if shown > g.GameState.RemainingTime {
+			shown = g.GameState.RemainingTime
 		}
+	case shown > g.GameState.RemainingTime:
+		shown = g.GameState.RemainingTime
+	}
+	if shown != g.timerShown {
+		g.timerShown = shown
+		g.drawTimer()
 	}
 }
+
+// drawTimer shows the time left as m:ss; regions leave the player's cursor
+// alone.
+func (g *Game) drawTimer() {
+	g.layout.Status.Print(Reset + g.timerColor + " TIMER: " + formatTimer(g.timerShown) + Reset)
+}
+
+// formatTimer shows d as minutes and seconds, rounding part seconds up so
+// the timer only reads 0:00 when time is up.
+func formatTimer(d time.Duration) string {
+	seconds := int((d + time.Second - 1) / time.Second)
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newTestGame returns a game on the play screen, keeping its files in a
+// directory of the test's own.
+func newTestGame(t *testing.T) *Game {
+	t.Helper()
+	useDataDir(t)
+	g := &Game{User: User{Alias: "Tester", NodeNum: 1, W: 80, H: 25, Awards: map[string]bool{}}}
+	g.GameState.AppState = statePlaying
+	g.GameState.Difficulty = g.difficulty().Name
+	g.setLayout(playLayout(80, 25))
+	g.resetRoom()
+	return g
+}
+
+// useDataDir keeps the shared files in a directory of the test's own for
+// the rest of the test.
+func useDataDir(t *testing.T) {
+	t.Helper()
+	saved := dataDir
+	dataDir = t.TempDir()
+	t.Cleanup(func() { dataDir = saved })
+}
+
+// useManualClock swaps in a manual clock for the rest of the test.
+func useManualClock(t *testing.T) *manualClock {
+	t.Helper()
+	c := newManualClock(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	saved := clock
+	clock = c
+	t.Cleanup(func() { clock = saved })
+	return c
+}
+
+func TestManualClockAdvance(t *testing.T) {
+	tests := []struct {
+		name    string
+		every   time.Duration
+		advance time.Duration
+		want    int
+	}{
+		{"not yet", time.Second, 999 * time.Millisecond, 0},
+		{"on the tick", time.Second, time.Second, 1},
+		{"part way to the next", time.Second, 2500 * time.Millisecond, 2},
+		{"timer frames", timerFrame, time.Second, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newManualClock(time.Unix(0, 0))
+			ticker := c.NewTicker(tt.every)
+			c.Advance(tt.advance)
+			if got := len(ticker.Ticks()); got != tt.want {
+				t.Errorf("got %d ticks, want %d", got, tt.want)
+			}
+			if got := c.Now().Sub(time.Unix(0, 0)); got != tt.advance {
+				t.Errorf("clock moved %v, want %v", got, tt.advance)
+			}
+		})
+	}
+}
+
+func TestManualClockTickers(t *testing.T) {
+	c := newManualClock(time.Unix(0, 0))
+	seconds := c.NewTicker(time.Second)
+	halves := c.NewTicker(500 * time.Millisecond)
+	stopped := c.NewTicker(time.Second)
+	stopped.Stop()
+	c.Sleep(2 * time.Second)
+
+	tests := []struct {
+		name   string
+		ticker Ticker
+		want   []time.Duration
+	}{
+		{"seconds", seconds, []time.Duration{time.Second, 2 * time.Second}},
+		{"halves", halves, []time.Duration{500 * time.Millisecond, time.Second, 1500 * time.Millisecond, 2 * time.Second}},
+		{"stopped", stopped, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(tt.ticker.Ticks()); got != len(tt.want) {
+				t.Fatalf("got %d ticks, want %d", got, len(tt.want))
+			}
+			for _, want := range tt.want {
+				if got := (<-tt.ticker.Ticks()).Sub(time.Unix(0, 0)); got != want {
+					t.Errorf("tick at %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTickCountsDown(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   time.Duration
+		seconds int
+		pills   time.Duration // pill timer, if the pills were eaten
+		want    time.Duration
+		wantPT  time.Duration
+	}{
+		{"counts down a second a tick", 40 * time.Second, 3, 0, 37 * time.Second, 0},
+		{"pills count down too", 40 * time.Second, 5, 45 * time.Second, 35 * time.Second, 40 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := useManualClock(t)
+			g := newTestGame(t)
+			g.GameState.RemainingTime = tt.start
+			g.timerShown = tt.start
+			g.GameState.Pills = tt.pills > 0
+			g.GameState.PillTimer = tt.pills
+			ticker := clock.NewTicker(time.Second)
+			defer ticker.Stop()
+
+			c.Advance(time.Duration(tt.seconds) * time.Second)
+			for len(ticker.Ticks()) > 0 {
+				<-ticker.Ticks()
+				g.tick(nil)
+			}
+			if g.GameState.RemainingTime != tt.want {
+				t.Errorf("time left %v, want %v", g.GameState.RemainingTime, tt.want)
+			}
+			if g.GameState.PillTimer != tt.wantPT {
+				t.Errorf("pill timer %v, want %v", g.GameState.PillTimer, tt.wantPT)
+			}
+			if g.timerShown != tt.want {
+				t.Errorf("timer shows %v, want %v", g.timerShown, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunDue(t *testing.T) {
+	tests := []struct {
+		name string
+		left time.Duration
+		want []string
+	}{
+		{"none due", 30 * time.Second, nil},
+		{"on the second", 20 * time.Second, []string{"a", "c"}},
+		{"all due, in order", 5 * time.Second, []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGame(t)
+			var ran []string
+			g.at(20*time.Second, func() { ran = append(ran, "a") })
+			g.at(10*time.Second, func() { ran = append(ran, "b") })
+			g.at(20*time.Second, func() { ran = append(ran, "c") })
+			g.GameState.RemainingTime = tt.left
+			g.runDue()
+			g.runDue() // nothing runs twice
+			if fmt.Sprint(ran) != fmt.Sprint(tt.want) {
+				t.Errorf("ran %v, want %v", ran, tt.want)
+			}
+			if got := len(g.events); got != 3-len(tt.want) {
+				t.Errorf("%d events left, want %d", got, 3-len(tt.want))
+			}
+		})
+	}
+}
+
+func TestScheduleUrgency(t *testing.T) {
+	tests := []struct {
+		name  string
+		left  time.Duration
+		farts int
+		want  string
+	}{
+		{"plenty of time", 30 * time.Second, 0, Green},
+		{"first warning", 19 * time.Second, 0, YellowHi},
+		{"last warning", 4 * time.Second, 0, RedHi},
+		{"farted in time", 4 * time.Second, 1, Green},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGame(t)
+			g.scheduleUrgency()
+			g.GameState.Farts = tt.farts
+			g.GameState.RemainingTime = tt.left
+			g.runDue()
+			if g.timerColor != tt.want {
+				t.Errorf("timer color %q, want %q", g.timerColor, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadUrgency(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		want    []UrgencyStage
+		wantErr bool
+	}{
+		{
+			name: "latest first",
+			file: `[{"Left":"5s","Message":"Now!","Color":"RedHi","TimerColor":"red"},
+				{"Left":"1m","Message":"Soon","Color":"cyan","TimerColor":"cyanhi"}]`,
+			want: []UrgencyStage{
+				{Left: time.Minute, Message: "Soon", Color: Cyan, TimerColor: CyanHi},
+				{Left: 5 * time.Second, Message: "Now!", Color: RedHi, TimerColor: Red},
+			},
+		},
+		{name: "none at all", file: `[]`},
+		{name: "not a duration", file: `[{"Left":"19","Message":"x","Color":"red","TimerColor":"red"}]`, wantErr: true},
+		{name: "unknown color", file: `[{"Left":"19s","Message":"x","Color":"puce","TimerColor":"red"}]`, wantErr: true},
+		{name: "not JSON", file: `Left=19s`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "urgency.json")
+			if err := os.WriteFile(path, []byte(tt.file), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := loadUrgency(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, want error %v", err, tt.wantErr)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// nodesDir holds a status file for each node in the door, under dataDir.
+const nodesDir = "nodes"
+
+// NodeStatus is what a node tells the others about itself. Heartbeat is
+// renewed while the node runs; one that stops is a node that dropped.
+type NodeStatus struct {
+	Node      int
+	Alias     string
+	Doing     string
+	Lobby     bool      // waiting for someone to race
+	Since     time.Time // when it started doing it
+	Heartbeat time.Time
+}
+
+// stateActivities say what a player in each state is doing.
+var stateActivities = map[int]string{
+	stateMainMenu:   "at the main menu",
+	stateIntro:      "getting ready",
+	stateGameOver:   "looking at the damage",
+	stateHelp:       "reading the help",
+	stateCredits:    "reading the credits",
+	stateAwards:     "admiring awards",
+	stateScores:     "reading the hall of fame",
+	stateNews:       "reading the news",
+	stateWho:        "checking who's online",
+	stateLobby:      "waiting for a race",
+	stateRaceResult: "seeing who won the race",
+	stateDaily:      "eyeing the daily challenge",
+}
+
+// nodeStatusPath returns where node's status is kept.
+func nodeStatusPath(node int) string {
+	return dataPath(filepath.Join(nodesDir, strconv.Itoa(node)+".json"))
+}
+
+// activity says what the player is doing now.
+func (g *Game) activity() string {
+	if g.GameState.AppState == statePlaying {
+		if g.race != nil {
+			return "racing " + g.race.OpponentAlias
+		}
+		return "playing on " + g.difficulty().Name
+	}
+	return stateActivities[g.GameState.AppState]
+}
+
+// updateStatus notes what the player is up to now and passes it on.
+func (g *Game) updateStatus() {
+	doing := g.activity()
+	g.statusMutex.Lock()
+	if doing != g.status.Doing {
+		g.status = NodeStatus{Node: g.User.NodeNum, Alias: g.User.Alias, Doing: doing, Since: clock.Now(),
+			Lobby: g.GameState.AppState == stateLobby}
+	}
+	g.statusMutex.Unlock()
+	g.heartbeat()
+}
+
+// heartbeat tells the other nodes this one is still here, and what its
+// player is up to.
+func (g *Game) heartbeat() {
+	g.statusMutex.Lock()
+	defer g.statusMutex.Unlock()
+	g.status.Heartbeat = clock.Now()
+	if err := saveJSON(nodeStatusPath(g.User.NodeNum), g.status); err != nil {
+		inputLog(LogLevelError, "SysOp", "Failed to write the node status: "+err.Error())
+	}
+	g.joinSpool()
+}
+
+// keepAlive sends heartbeats from a goroutine of its own, so a player
+// sitting at a prompt isn't taken for gone. It returns a function that
+// stops them.
+func (g *Game) keepAlive() func() {
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := clock.NewTicker(noticePoll)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.Ticks():
+				g.heartbeat()
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-stopped
+	}
+}
+
+// leaveNodes takes this node off the who's online list.
+func (g *Game) leaveNodes() {
+	os.Remove(nodeStatusPath(g.User.NodeNum))
+}
+
+// onlineNodes lists the nodes in the door, lowest first, and sweeps away
+// the status of nodes that have dropped.
+func onlineNodes() []NodeStatus {
+	files, _ := filepath.Glob(dataPath(filepath.Join(nodesDir, "*.json")))
+	now := clock.Now()
+	var nodes []NodeStatus
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		var status NodeStatus
+		if err != nil || json.Unmarshal(data, &status) != nil {
+			continue
+		}
+		if now.Sub(status.Heartbeat) > nodeStale {
+			os.Remove(file)
+			continue
+		}
+		nodes = append(nodes, status)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Node < nodes[j].Node })
+	return nodes
+}
+
+// showWho prints who is in the door and what they're doing.
+func (g *Game) showWho() {
+	nodes := onlineNodes()
+	CenterText("WHO'S ONLINE", g.User.W)
+	Println("")
+
+	row, aliasWidth, doingWidth := "%-5s%-14s%-14s%5s", 13, 13
+	if g.User.W >= 80 {
+		row, aliasWidth, doingWidth = "%-6s%-20s%-30s%6s", 19, 29
+	}
+	Printf(Cyan+row+Reset+"\n", "Node", "Alias", "Doing", "For")
+	now := clock.Now()
+	for _, n := range nodes {
+		color := White
+		if n.Node == g.User.NodeNum {
+			color = WhiteHi
+		}
+		Printf(color+row+Reset+"\n", strconv.Itoa(n.Node), clip(n.Alias, aliasWidth), clip(n.Doing, doingWidth),
+			formatTimer(now.Sub(n.Since)))
+	}
+}